@@ -0,0 +1,331 @@
+package replyme
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	aliasCommandName   = "alias"
+	unaliasCommandName = "unalias"
+)
+
+// loadAliases reads the aliases saved with `alias`. A file that does not exist has no aliases.
+func loadAliases(file string) (map[string]string, error) {
+	aliases := map[string]string{}
+	if file == "" {
+		return aliases, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return aliases, nil
+	}
+
+	if err == nil {
+		err = toml.Unmarshal(data, &aliases)
+	}
+
+	if err != nil {
+		return nil, newErrorConfigFile(file, err)
+	}
+
+	return aliases, nil
+}
+
+// saveAliases writes the aliases defined with `alias` to App.AliasesFile, if it is set.
+func (a *App) saveAliases() error {
+	if a.AliasesFile == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.AliasesFile), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+
+	f, err := os.Create(a.AliasesFile)
+	if err != nil {
+		return err
+	}
+
+	a.aliasesMu.RLock()
+	err = toml.NewEncoder(f).Encode(a.userAliases)
+	a.aliasesMu.RUnlock()
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// lookupAlias returns the value of the alias. The aliases defined with `alias` override App.Aliases.
+func (a *App) lookupAlias(name string) (string, bool) {
+	a.aliasesMu.RLock()
+	defer a.aliasesMu.RUnlock()
+
+	if value, ok := a.userAliases[name]; ok {
+		return value, true
+	}
+
+	value, ok := a.Aliases[name]
+
+	return value, ok
+}
+
+// allAliases returns App.Aliases with the aliases defined with `alias` over them.
+func (a *App) allAliases() map[string]string {
+	a.aliasesMu.RLock()
+	defer a.aliasesMu.RUnlock()
+
+	aliases := maps.Clone(a.Aliases)
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
+
+	maps.Copy(aliases, a.userAliases)
+
+	return aliases
+}
+
+// expandAliases replaces the aliases at the start of the commands of the pipeline with their values.
+// The aliases in skip are being expanded, so they are left as is, like in the shell: `alias ls='ls -l'`
+// runs the ls command, and aliases that refer to each other do not expand forever.
+// It returns the expanded command line and the aliases it expanded, or nil if there were none.
+func (a *App) expandAliases(words []string, skip map[string]bool) (string, []string) {
+	var expanded []string
+
+	var parts []string
+
+	for start := 0; start < len(words); {
+		end := start + slices.IndexFunc(words[start:], isPipelineOperator)
+		if end < start {
+			end = len(words)
+		}
+
+		command := words[start:end]
+
+		if name, value, ok := a.commandAlias(command[0], skip); ok {
+			expanded = append(expanded, name)
+
+			value, used := substituteAliasArgs(value, command[1:])
+			if !used {
+				value = strings.Join(append([]string{value}, command[1:]...), " ")
+			}
+
+			parts = append(parts, value)
+		} else {
+			parts = append(parts, strings.Join(command, " "))
+		}
+
+		if end < len(words) {
+			parts = append(parts, words[end])
+		}
+
+		start = end + 1
+	}
+
+	return strings.Join(parts, " "), expanded
+}
+
+// commandAlias returns the alias the first word of a command refers to, unless it is in skip.
+func (a *App) commandAlias(word string, skip map[string]bool) (name, value string, ok bool) {
+	words, err := tokenize(word)
+	if err != nil || len(words) != 1 || skip[words[0]] {
+		return "", "", false
+	}
+
+	value, ok = a.lookupAlias(words[0])
+
+	return words[0], value, ok
+}
+
+// substituteAliasArgs replaces `$1`, `${1}`, `$@` and `$#` in the value of an alias with the words
+// after it, which keep their quotes. used is false if the value has none of them.
+func substituteAliasArgs(value string, args []string) (string, bool) {
+	var b strings.Builder
+
+	var inSingleQuote, escape, used bool
+
+	runes := []rune(value)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if escape || inSingleQuote || r != '$' || i+1 >= len(runes) {
+			switch {
+			case escape:
+				escape = false
+			case r == '\\':
+				escape = true
+			case r == '\'':
+				inSingleQuote = !inSingleQuote
+			}
+
+			b.WriteRune(r)
+
+			continue
+		}
+
+		name, n := "", 0
+
+		switch next := runes[i+1]; {
+		case next == '@' || next == '#' || isPositional(string(next)):
+			name, n = string(next), 2 //nolint:mnd
+		case next == '{':
+			if end := slices.Index(runes[i:], '}'); end != -1 && isPositional(string(runes[i+2:i+end])) {
+				name, n = string(runes[i+2:i+end]), end+1
+			}
+		}
+
+		if name == "" {
+			b.WriteRune(r)
+
+			continue
+		}
+
+		used = true
+		i += n - 1
+
+		switch name {
+		case "@":
+			b.WriteString(strings.Join(args, " "))
+		case "#":
+			b.WriteString(strconv.Itoa(len(args)))
+		default:
+			if index, _ := strconv.Atoi(name); index >= 1 && index <= len(args) {
+				b.WriteString(args[index-1])
+			}
+		}
+	}
+
+	return b.String(), used
+}
+
+// setAliasCommands adds the `alias` and `unalias` commands, unless the application has commands with these names.
+func (a *App) setAliasCommands() {
+	definitions := &Argument{
+		Name:     "definitions",
+		Usage:    L(i18n_app_alias_definitions_usage),
+		Optional: true,
+		Variadic: true,
+	}
+	names := &Argument{Name: "names", Usage: L(i18n_app_unalias_names_usage), Variadic: true}
+
+	commands := Commands{
+		{
+			Name:      aliasCommandName,
+			Usage:     L(i18n_app_alias_usage),
+			Arguments: []*Argument{definitions},
+			Action: func(ctx *Context) error {
+				return a.defineAliases(ctx.Stdout(), MustGetArg[[]string](ctx, definitions.Name))
+			},
+		},
+		{
+			Name:      unaliasCommandName,
+			Usage:     L(i18n_app_unalias_usage),
+			Arguments: []*Argument{names},
+			Action: func(ctx *Context) error {
+				return a.removeAliases(MustGetArg[[]string](ctx, names.Name))
+			},
+		},
+	}
+
+	a.addBuiltinCommands(commands...)
+}
+
+// defineAliases runs `alias`: `name=value` defines an alias, `name` prints it,
+// and without definitions every alias is printed.
+func (a *App) defineAliases(w io.Writer, definitions []string) error {
+	if len(definitions) == 0 {
+		aliases := a.allAliases()
+		for _, name := range slices.Sorted(maps.Keys(aliases)) {
+			if err := printAlias(w, name, aliases[name]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	changed := false
+
+	for _, definition := range definitions {
+		name, value, ok := strings.Cut(definition, "=")
+		if !isFunctionName(name) {
+			return newErrorInvalidAlias(name)
+		}
+
+		if !ok {
+			value, found := a.lookupAlias(name)
+			if !found {
+				return newErrorUnknownAlias(name)
+			}
+
+			if err := printAlias(w, name, value); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		a.aliasesMu.Lock()
+		if a.userAliases == nil {
+			a.userAliases = map[string]string{}
+		}
+
+		a.userAliases[name] = value
+		a.aliasesMu.Unlock()
+
+		changed = true
+	}
+
+	if changed {
+		return a.saveAliases()
+	}
+
+	return nil
+}
+
+// removeAliases runs `unalias`. Only the aliases defined with `alias` can be removed, not App.Aliases.
+// The names that are not aliases are reported after the others are removed.
+func (a *App) removeAliases(names []string) error {
+	var err error
+
+	for _, name := range names {
+		a.aliasesMu.Lock()
+		_, ok := a.userAliases[name]
+		delete(a.userAliases, name)
+		a.aliasesMu.Unlock()
+
+		switch _, predefined := a.Aliases[name]; {
+		case ok || err != nil:
+		case predefined:
+			err = newErrorPredefinedAlias(name)
+		default:
+			err = newErrorUnknownAlias(name)
+		}
+	}
+
+	if saveErr := a.saveAliases(); saveErr != nil {
+		return saveErr
+	}
+
+	return err
+}
+
+// printAlias prints the alias the way it is defined, so that the line can be entered again.
+func printAlias(w io.Writer, name, value string) error {
+	_, err := fmt.Fprintf(w, "%s='%s'\n", name, strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value))
+
+	return err
+}
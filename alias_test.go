@@ -0,0 +1,129 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubstituteAliasArgs(t *testing.T) {
+	tests := []struct {
+		value string
+		args  []string
+		want  string
+		used  bool
+	}{
+		{"deploy status --env prod", []string{"-v"}, "deploy status --env prod", false},
+		{"deploy $1 --env ${2}", []string{"web", "'stage 1'"}, "deploy web --env 'stage 1'", true},
+		{"echo $@ ($#) $3", []string{"a", "b"}, "echo a b (2) ", true},
+		{`echo '$1' \$2 $HOME $`, []string{"a", "b"}, `echo '$1' \$2 $HOME $`, false},
+	}
+
+	for _, tt := range tests {
+		got, used := substituteAliasArgs(tt.value, tt.args)
+		if got != tt.want || used != tt.used {
+			t.Errorf("substituteAliasArgs(%q, %q) = %q, %v, want %q, %v", tt.value, tt.args, got, used, tt.want, tt.used)
+		}
+	}
+}
+
+func TestExpandAliases(t *testing.T) {
+	app := &App{
+		Aliases:     map[string]string{"ul": "users list", "admins": "filter --role admin", "ls": "ls -l"},
+		userAliases: map[string]string{"ul": "users list --all"},
+	}
+
+	tests := []struct {
+		input    string
+		skip     map[string]bool
+		want     string
+		expanded []string
+	}{
+		{"ul | admins > out.txt", nil, "users list --all | filter --role admin > out.txt", []string{"ul", "admins"}},
+		{"users list | admins", nil, "users list | filter --role admin", []string{"admins"}},
+		{`"ul" x`, nil, "users list --all x", []string{"ul"}},
+		{"ls", map[string]bool{"ls": true}, "ls", nil},
+		{"echo ul", nil, "echo ul", nil},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, expanded := app.expandAliases(program[0].(*commandStatement).words, tt.skip)
+		if got != tt.want || strings.Join(expanded, ",") != strings.Join(tt.expanded, ",") {
+			t.Errorf("expandAliases(%q) = %q, %q, want %q, %q", tt.input, got, expanded, tt.want, tt.expanded)
+		}
+	}
+}
+
+func TestAliasCommands(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app", "aliases.toml")
+
+	newApp := func() *App {
+		app := newScriptApp(nil)
+		app.Aliases = map[string]string{"admins": "users list | filter --role admin", "echo": "echo [$@]"}
+		app.AliasesFile = file
+
+		return app
+	}
+
+	script := `alias greet='echo hello $1 ($#)' twice='greet $1 && greet $1'
+twice bob
+admins
+alias loop=loop
+loop
+`
+
+	out, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	err := RunScript(newApp(), strings.NewReader(script), ScriptParams{
+		Name:            "aliases.rply",
+		ContinueOnError: true,
+		Stdout:          out,
+		Stderr:          stderr,
+	})
+
+	var unknown *UnknownCommandError
+	if !errors.As(err, &unknown) || unknown.Name != "loop" {
+		t.Errorf("got %v, want the alias that refers to itself to run as an unknown command", err)
+	}
+
+	if want := "[hello bob (1)]\n[hello bob (1)]\nalice\ncarol\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil || !strings.Contains(string(data), `greet = "echo hello $1 ($#)"`) {
+		t.Fatalf("the aliases are not saved: %q, %v", data, err)
+	}
+
+	out.Reset()
+
+	err = RunScript(newApp(), strings.NewReader("unalias greet loop\nalias\n"), ScriptParams{Stdout: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "admins='users list | filter --role admin'\necho='echo [$@]'\ntwice='greet $1 && greet $1'\n"
+	if out.String() != want {
+		t.Errorf("alias printed %q, want %q", out.String(), want)
+	}
+
+	for input, want := range map[string]error{
+		"unalias admins":   ErrorPredefinedAlias,
+		"unalias missing":  ErrorUnknownAlias,
+		"alias missing":    ErrorUnknownAlias,
+		"alias '1x=users'": ErrorInvalidAlias,
+	} {
+		err = RunScript(newApp(), strings.NewReader(input), ScriptParams{Stdout: &bytes.Buffer{}})
+		if !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", input, err, want)
+		}
+	}
+}
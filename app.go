@@ -1,6 +1,12 @@
 package replyme
 
-import "golang.org/x/exp/slices"
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
 
 // AppParams - the structure of the application parameters.
 type AppParams struct {
@@ -25,6 +31,38 @@ type App struct {
 
 	// A list of all your commands
 	Commands Commands
+	// Flags that are accepted by every command, at any depth
+	GlobalFlags Flags
+	// If set, every flag is also read from the PREFIX_FLAG_NAME environment variable
+	EnvPrefix string
+	// TOML, JSON or YAML files the flags are read from if they are set neither on the command line
+	// nor in the environment. Top-level keys are the global flags, and tables are the commands,
+	// e.g. `[deploy.status] timeout = "30s"`. Later files override earlier ones, missing files are skipped.
+	// The `config show` command prints the effective values and where each one comes from
+	ConfigFiles []string
+	// Shortcuts for command lines in the REPL and scripts, e.g. "prod-status": "deploy status --env prod --verbose".
+	// An alias at the start of a command is replaced with its value, and `$1`, `$2`... and `$@` in the value
+	// are replaced with the words after it, which are appended to the value otherwise. Unlike Command.Aliases,
+	// they can expand to any command line. Users can define their own ones with the `alias` command
+	Aliases map[string]string
+	// The TOML file the aliases defined with `alias` are saved to and loaded from, e.g. in os.UserConfigDir.
+	// If it is empty, they are kept until the application exits
+	AliasesFile string
+	// How long a command can run if neither it nor its parents have a Command.Timeout. 0 is no timeout.
+	// It does not apply to the built-in `fg`, `wait` and `source`, which run as long as the commands they wait for
+	DefaultTimeout time.Duration
+	// Allows flags that take a single value to be given more than once, the last value wins.
+	// By default, this is an error
+	AllowRepeatedFlags bool
+	// Allows commands to be called by a unique prefix of their name or alias, e.g. `dep st` for `deploy status`
+	AllowPrefixMatch bool
+	// Makes command names and aliases case-insensitive
+	CaseInsensitive bool
+	// Accepts flags that the command does not declare. By default, they are rejected with UnknownFlagError
+	AllowUnknownFlags bool
+	// Makes `|`, `<`, `>` and `>>` given as separate arguments of the program pipeline operators,
+	// e.g. `app users list '|' filter '>' admins.txt`. By default, they are passed to the command as is
+	CLIPipelines bool
 
 	// Allows you to enable Debug mode (with it, all Debug messages are output to the console)
 	Debug bool
@@ -32,54 +70,63 @@ type App struct {
 	NoColor bool
 	// Application parameters. For more information, see AppParams.
 	Params AppParams
+
+	config      configLayers
+	userAliases map[string]string
+	aliasesMu   sync.RWMutex
+	// The jobs started with `&` in the REPL
+	jobs jobTable
+	// The session variables and functions
+	session session
 }
 
-func (a *App) getFlagSchema() flagSchema {
-	return parseFlagSchema(a.Commands)
+func (a *App) parseOptions() parseOptions {
+	return parseOptions{
+		AllowPrefixMatch:  a.AllowPrefixMatch,
+		CaseInsensitive:   a.CaseInsensitive,
+		AllowUnknownFlags: a.AllowUnknownFlags,
+	}
+}
+
+// parse parses the command line against the commands of the application.
+func (a *App) parse(input string) (*ASTNode, error) {
+	return a.parseWith(input, a.parseOptions())
+}
+
+func (a *App) parseWith(input string, opts parseOptions) (*ASTNode, error) {
+	return parseCommand(createCommandSchema(a.Commands), a.getFlagSchema(), createArgsSchema(a.Commands), input, opts)
 }
 
-func parseFlagSchema(commands Commands) flagSchema {
-	schema := flagSchema{}
+func (a *App) getFlagSchema() flagSchema {
+	schema := createFlagSchema(a.Commands)
 
-	for _, cmd := range commands {
-		newSchema := parseFlagSchemaSingle(cmd)
-		for k, v := range newSchema {
-			schema[k] = v
+	if len(a.GlobalFlags) > 0 {
+		schema[globalSchemaKey] = map[string]FlagType{}
+		for _, flag := range a.GlobalFlags {
+			addFlagType(schema[globalSchemaKey], flag)
 		}
 	}
 
 	return schema
 }
 
-func parseFlagSchemaSingle(command *Command) flagSchema {
-	allFlags := make(map[string]map[string]FlagType)
-	f := make(map[string]FlagType)
-
-	for _, flag := range command.Flags {
-		switch flag.ValueType() {
-		case "bool":
-			f[flag.GetName()] = FlagTypeBool
-		case "string":
-			f[flag.GetName()] = FlagTypeString
-		case "int":
-			f[flag.GetName()] = FlagTypeInt
-		case "[]string":
-			f[flag.GetName()] = FlagTypeStringArray
-		case "[]int":
-			f[flag.GetName()] = FlagTypeIntArray
-		}
-	}
+// hasCommand reports whether a top-level command of the application has the name or alias name.
+func (a *App) hasCommand(name string) bool {
+	return slices.ContainsFunc(a.Commands, func(c *Command) bool {
+		return slices.ContainsFunc(append([]string{c.Name}, c.Aliases...), func(n string) bool {
+			return n == name || (a.CaseInsensitive && strings.EqualFold(n, name))
+		})
+	})
+}
 
-	if len(command.Subcommands) > 0 {
-		schema := parseFlagSchema(command.Subcommands)
-		for k, v := range schema {
-			allFlags[k] = v
+// addBuiltinCommands adds the built-in commands, except the ones whose name is already used
+// by a command of the application, either as its name or as an alias.
+func (a *App) addBuiltinCommands(commands ...*Command) {
+	for _, command := range commands {
+		if !a.hasCommand(command.Name) {
+			a.Commands = append(a.Commands, command)
 		}
 	}
-
-	allFlags[command.Name] = f
-
-	return allFlags
 }
 
 func (a *App) setHelpFlags() {
@@ -88,7 +135,7 @@ func (a *App) setHelpFlags() {
 
 func setHelpFlag(commands []*Command) []*Command {
 	for i := range commands {
-		if slices.IndexFunc(commands[i].Flags, func(flag Flag) bool {
+		if slices.IndexFunc(commands[i].Flags, func(flag FlagDefinition) bool {
 			return flag.GetName() == "help"
 		}) == -1 {
 			commands[i].Flags = append(commands[i].Flags, &FlagValue[bool]{
@@ -1,116 +1,11 @@
 package replyme
 
 import (
-	"github.com/go-faker/faker/v4"
 	"reflect"
 	"slices"
 	"testing"
 )
 
-//nolint:cyclop
-func TestParseFlagSchema(t *testing.T) {
-	commands := make(Commands, 200)
-	for i := range commands {
-		flags := make(Flags, 200)
-		for i := range flags {
-			var flag Flag
-
-			n, err := faker.RandomInt(1, 5)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			switch n[0] {
-			case 1:
-				flag = &FlagValue[string]{
-					Name: faker.UUIDHyphenated(),
-				}
-			case 2:
-				flag = &FlagValue[int]{
-					Name: faker.UUIDHyphenated(),
-				}
-			case 3:
-				flag = &FlagValue[bool]{
-					Name: faker.UUIDHyphenated(),
-				}
-			case 4:
-				flag = &FlagValue[[]string]{
-					Name: faker.UUIDHyphenated(),
-				}
-			case 5:
-				flag = &FlagValue[[]int]{
-					Name: faker.UUIDHyphenated(),
-				}
-			}
-
-			flags[i] = flag
-		}
-
-		command := &Command{
-			Name:  faker.UUIDHyphenated(),
-			Usage: faker.Sentence(),
-			Flags: flags,
-		}
-		commands[i] = command
-	}
-
-	schema := parseFlagSchema(commands)
-	cmdLen := 0
-	flagsLen := 0
-
-	for _, v := range schema {
-		cmdLen++
-
-		for range v {
-			flagsLen++
-		}
-	}
-
-	if cmdLen != 200 || flagsLen != 40000 {
-		t.Fatal("parse flag schema failed")
-	}
-}
-
-func TestParseFlagSchemaSingle(t *testing.T) {
-
-	command := &Command{
-		Name:  "test",
-		Usage: faker.Sentence(),
-		Flags: []Flag{
-			&FlagValue[string]{
-				Name: "a",
-			},
-			&FlagValue[int]{
-				Name: "b",
-			},
-			&FlagValue[bool]{
-				Name: "c",
-			},
-			&FlagValue[[]int]{
-				Name: "d",
-			},
-			&FlagValue[[]string]{
-				Name: "e",
-			},
-		},
-	}
-	value := flagSchema{
-		"test": {
-			"a": FlagTypeString,
-			"b": FlagTypeInt,
-			"c": FlagTypeBool,
-			"d": FlagTypeIntArray,
-			"e": FlagTypeStringArray,
-		},
-	}
-
-	schema := parseFlagSchemaSingle(command)
-
-	if !reflect.DeepEqual(value, schema) {
-		t.Fatal("parse flag schema failed", value, schema)
-	}
-}
-
 func TestApp_ParseFlagSchema(t *testing.T) {
 	app := &App{
 		Commands: Commands{
@@ -147,7 +42,7 @@ func TestApp_ParseFlagSchema(t *testing.T) {
 			"a": FlagTypeString,
 			"b": FlagTypeInt,
 		},
-		"sub": {
+		"test/sub": {
 			"c": FlagTypeBool,
 		},
 	}
@@ -195,7 +90,7 @@ func TestApp_SetHelpFlags(t *testing.T) {
 
 func setHelpFlagsChecker(t *testing.T, commands Commands) {
 	for _, cmd := range commands {
-		if slices.IndexFunc(cmd.Flags, func(flag Flag) bool {
+		if slices.IndexFunc(cmd.Flags, func(flag FlagDefinition) bool {
 			return flag.GetName() == "help"
 		}) == -1 {
 			t.Fatal("help flag not set")
@@ -205,3 +100,34 @@ func setHelpFlagsChecker(t *testing.T, commands Commands) {
 		}
 	}
 }
+
+func TestApp_AddBuiltinCommands(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{
+		CaseInsensitive: true,
+		Commands: Commands{
+			{Name: "variables", Aliases: []string{"vars"}},
+			{Name: "terminate", Aliases: []string{"KILL"}},
+			{Name: "run-script", Aliases: []string{"source"}},
+			{Name: "alias"},
+		},
+	}
+
+	app.setSessionCommands()
+	app.setJobCommands()
+	app.setAliasCommands()
+	app.setSourceCommand()
+
+	if err := validateCommands(app.Commands, nil, app.GlobalFlags, app.parseOptions()); err != nil {
+		t.Fatalf("the built-in commands clash with the application commands: %v", err)
+	}
+
+	for _, name := range []string{"set", "unset", "jobs", "fg", "wait", "unalias"} {
+		if !app.hasCommand(name) {
+			t.Errorf("the built-in command %s is not added", name)
+		}
+	}
+}
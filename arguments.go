@@ -1,17 +1,441 @@
 package replyme
 
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Arg is an interface for getting information about positional arguments and parsing them.
+type Arg interface {
+	// GetName returns the name of the argument.
+	GetName() string
+	// GetUsage returns the usage of the argument.
+	GetUsage() string
+	// IsOptional returns whether the argument can be omitted.
+	IsOptional() bool
+	// IsVariadic returns whether the argument takes all the remaining values.
+	IsVariadic() bool
+	// GetDefault returns the default value of the argument formatted as a string, or "" if there is none.
+	GetDefault() string
+	// Parse parses a value of the argument. A variadic argument is parsed once for every value.
+	Parse(value string) error
+	// ApplyDefault sets the default value if the argument has no value yet.
+	ApplyDefault()
+	// ParsedValue returns the parsed value of the argument, a slice for variadic arguments.
+	ParsedValue() (interface{}, error)
+	// Completions returns the values offered when completing the argument.
+	Completions(ctx *Context, partial string) []string
+	// Clear clears the argument.
+	Clear()
+}
+
+// Arguments is an abbreviation for the type `[]Arg`.
+type Arguments []Arg
+
 // Argument is a structure that describes the arguments for your command.
 type Argument struct {
 	Name  string
 	Usage string
-	value string
+	// Returns the values offered when the argument is completed with Tab
+	Complete func(ctx *Context, partial string) []string
+	// The argument can be omitted
+	Optional bool
+	// The value used when the argument is omitted
+	Default string
+	// The argument takes all the remaining values, e.g. `<src>...`. Only one argument of a command can be variadic
+	Variadic bool
+	// Checks every value of the argument before the command runs
+	Validate func(value string) error
+	value    string
+	values   []string
 }
 
-// GetValue - method for getting the value of an argument.
+// GetValue - method for getting the value of an argument. The values of a variadic argument are joined with spaces.
+// A run of the command is parsed into a copy of the argument, and the argument gets the value of the last run
+// that started. When a command can run several times at once, e.g. in background jobs, read the value with GetArg.
 func (arg *Argument) GetValue() string {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
 	return arg.value
 }
 
+// GetValues returns all values of a variadic argument. Like GetValue, they are the values of the last run.
+func (arg *Argument) GetValues() []string {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
+	return arg.values
+}
+
 func (arg *Argument) setValue(v string) {
 	arg.value = v
+	arg.values = []string{v}
+}
+
+// GetName returns the name of the argument.
+func (arg *Argument) GetName() string {
+	return arg.Name
+}
+
+// GetUsage returns the usage of the argument.
+func (arg *Argument) GetUsage() string {
+	return arg.Usage
+}
+
+// IsOptional returns whether the argument can be omitted.
+func (arg *Argument) IsOptional() bool {
+	return arg.Optional
+}
+
+// IsVariadic returns whether the argument takes all the remaining values.
+func (arg *Argument) IsVariadic() bool {
+	return arg.Variadic
+}
+
+// GetDefault returns the default value of the argument.
+func (arg *Argument) GetDefault() string {
+	return arg.Default
+}
+
+// Parse parses a value of the argument.
+func (arg *Argument) Parse(value string) error {
+	if arg.Validate != nil {
+		if err := arg.Validate(value); err != nil {
+			return newErrorInvalidArgument(arg.Name, err)
+		}
+	}
+
+	arg.values = append(arg.values, value)
+	arg.value = strings.Join(arg.values, " ")
+
+	return nil
+}
+
+// ApplyDefault sets the default value if the argument has no value yet.
+func (arg *Argument) ApplyDefault() {
+	if len(arg.values) == 0 && arg.Default != "" {
+		arg.setValue(arg.Default)
+	}
+}
+
+// ParsedValue returns the value of the argument, a []string for variadic arguments.
+func (arg *Argument) ParsedValue() (interface{}, error) {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
+	if len(arg.values) == 0 {
+		return nil, errors.New("value is nil")
+	}
+
+	if arg.Variadic {
+		return arg.values, nil
+	}
+
+	return arg.value, nil
+}
+
+// Completions returns the values offered when completing the argument.
+func (arg *Argument) Completions(ctx *Context, partial string) []string {
+	if arg.Complete == nil {
+		return nil
+	}
+
+	return arg.Complete(ctx, partial)
+}
+
+// Clear clears the argument.
+func (arg *Argument) Clear() {
+	arg.value = ""
+	arg.values = nil
+}
+
+// clone returns a copy of the argument without a value, into which a run of the command is parsed.
+func (arg *Argument) clone() *Argument {
+	mirrorMu.RLock()
+	run := *arg
+	mirrorMu.RUnlock()
+
+	run.Clear()
+
+	return &run
+}
+
+func (arg *Argument) cloneArg() Arg {
+	return arg.clone()
+}
+
+// mirror sets the value of the argument to the value of its copy run. mirrorMu must be locked.
+func (arg *Argument) mirror(run *Argument) {
+	arg.value = run.value
+	arg.values = run.values
+}
+
+func (arg *Argument) mirrorArg(run Arg) {
+	if r, ok := run.(*Argument); ok {
+		arg.mirror(r)
+	}
+}
+
+// ArgumentValue is a structure for typed positional arguments, parsed like `FlagValue[T]`.
+// The values of a variadic argument are available as []T.
+type ArgumentValue[T any] struct {
+	Name  string
+	Usage string
+	// Argument parser. If it is not set, the parser of the flag type registry is used
+	Parser func(s string) (T, error)
+	// Returns the values offered when the argument is completed with Tab
+	Complete func(ctx *Context, partial string) []string
+	// The argument can be omitted
+	Optional bool
+	// The value used when the argument is omitted
+	Default T
+	// The argument takes all the remaining values. Only one argument of a command can be variadic
+	Variadic bool
+	// Checks every value of the argument before it is parsed
+	Validate func(value string) error
+	values   []T
+}
+
+// GetName returns the name of the argument.
+func (a *ArgumentValue[T]) GetName() string {
+	return a.Name
+}
+
+// GetUsage returns the usage of the argument.
+func (a *ArgumentValue[T]) GetUsage() string {
+	return a.Usage
+}
+
+// IsOptional returns whether the argument can be omitted.
+func (a *ArgumentValue[T]) IsOptional() bool {
+	return a.Optional
+}
+
+// IsVariadic returns whether the argument takes all the remaining values.
+func (a *ArgumentValue[T]) IsVariadic() bool {
+	return a.Variadic
+}
+
+// GetDefault returns the default value of the argument formatted as a string, or "" if there is none.
+func (a *ArgumentValue[T]) GetDefault() string {
+	if a.hasZeroDefault() {
+		return ""
+	}
+
+	return formatFlagValue(a.Default)
+}
+
+func (a *ArgumentValue[T]) hasZeroDefault() bool {
+	return reflect.ValueOf(&a.Default).Elem().IsZero()
+}
+
+// Parse parses a value of the argument.
+func (a *ArgumentValue[T]) Parse(value string) error {
+	if a.Validate != nil {
+		if err := a.Validate(value); err != nil {
+			return newErrorInvalidArgument(a.Name, err)
+		}
+	}
+
+	var parsed T
+
+	if a.Parser != nil {
+		v, err := a.Parser(value)
+		if err != nil {
+			return newErrorInvalidArgument(a.Name, err)
+		}
+
+		parsed = v
+	} else {
+		info := flagTypes.lookup(reflect.TypeOf((*T)(nil)).Elem())
+		if info == nil {
+			return newErrorUnknownFlagType(reflect.TypeOf((*T)(nil)).Elem().String())
+		}
+
+		v, err := info.parse(flagParseOptions{}, value)
+		if err != nil {
+			return newErrorInvalidArgument(a.Name, err)
+		}
+
+		parsed = v.(T)
+	}
+
+	a.values = append(a.values, parsed)
+
+	return nil
+}
+
+// ApplyDefault sets the default value if the argument has no value yet.
+func (a *ArgumentValue[T]) ApplyDefault() {
+	if len(a.values) == 0 && !a.hasZeroDefault() {
+		a.values = []T{a.Default}
+	}
+}
+
+// ParsedValue returns the value of the argument, a []T for variadic arguments.
+func (a *ArgumentValue[T]) ParsedValue() (interface{}, error) {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
+	if len(a.values) == 0 {
+		return nil, errors.New("value is nil")
+	}
+
+	if a.Variadic {
+		return a.values, nil
+	}
+
+	return a.values[0], nil
+}
+
+// Completions returns the values offered when completing the argument.
+func (a *ArgumentValue[T]) Completions(ctx *Context, partial string) []string {
+	if a.Complete == nil {
+		return nil
+	}
+
+	return a.Complete(ctx, partial)
+}
+
+// Clear clears the argument.
+func (a *ArgumentValue[T]) Clear() {
+	a.values = nil
+}
+
+// cloneArg returns a copy of the argument without a value, into which a run of the command is parsed.
+func (a *ArgumentValue[T]) cloneArg() Arg {
+	mirrorMu.RLock()
+	run := *a
+	mirrorMu.RUnlock()
+
+	run.Clear()
+
+	return &run
+}
+
+// mirrorArg sets the value of the argument to the value of its copy run. mirrorMu must be locked.
+func (a *ArgumentValue[T]) mirrorArg(run Arg) {
+	if r, ok := run.(*ArgumentValue[T]); ok {
+		a.values = r.values
+	}
+}
+
+// lookupArg returns the value of the argument with the specified name if it is set and has the type T.
+func lookupArg[T any](args Arguments, name string) (T, bool) {
+	var zero T
+
+	for _, arg := range args {
+		if arg.GetName() != name {
+			continue
+		}
+
+		p, err := arg.ParsedValue()
+		if err != nil {
+			continue
+		}
+
+		if v, ok := p.(T); ok {
+			return v, true
+		}
+	}
+
+	return zero, false
+}
+
+// findArg returns the argument with the specified name, or nil if there is none.
+func findArg(args Arguments, name string) Arg {
+	for _, arg := range args {
+		if arg.GetName() == name {
+			return arg
+		}
+	}
+
+	return nil
+}
+
+// GetArg returns the value of the argument with the specified name, and whether it is set and has the type T.
+// Variadic arguments have the type []T.
+func GetArg[T any](ctx *Context, name string) (T, bool) {
+	return lookupArg[T](ctx.command.arguments(), name)
+}
+
+// MustGetArg returns the value of the argument with the specified name,
+// or the zero value of T if it is not set or has a different type.
+func MustGetArg[T any](ctx *Context, name string) T {
+	v, _ := GetArg[T](ctx, name)
+
+	return v
+}
+
+// requiredArguments returns the number of values the arguments need at least.
+func requiredArguments(args Arguments) int {
+	required := 0
+
+	for _, arg := range args {
+		if !arg.IsOptional() {
+			required++
+		}
+	}
+
+	return required
+}
+
+// assignArguments distributes the positional values between the arguments. Required arguments take one value,
+// optional ones take a value while there are more values than required, and the variadic argument takes the rest.
+// It returns the values that are left over.
+func assignArguments(args Arguments, values []string) ([]ASTArgument, []string) {
+	extra := len(values) - requiredArguments(args)
+
+	var result []ASTArgument
+
+	for _, arg := range args {
+		n := 0
+
+		switch {
+		case arg.IsVariadic():
+			if !arg.IsOptional() {
+				n = 1
+			}
+
+			n += max(extra, 0)
+			extra = 0
+		case !arg.IsOptional():
+			n = 1
+		case extra > 0:
+			n = 1
+			extra--
+		}
+
+		n = min(n, len(values))
+
+		for _, value := range values[:n] {
+			result = append(result, ASTArgument{Name: arg.GetName(), Value: value})
+		}
+
+		values = values[n:]
+	}
+
+	return result, values
+}
+
+// argumentsUsage returns the arguments as they are written in a usage line, e.g. `<src>... [dst]`.
+func argumentsUsage(args Arguments) string {
+	parts := make([]string, len(args))
+
+	for i, arg := range args {
+		name := arg.GetName()
+		if arg.IsVariadic() {
+			name += "..."
+		}
+
+		if arg.IsOptional() {
+			parts[i] = "[" + name + "]"
+		} else {
+			parts[i] = "<" + name + ">"
+		}
+	}
+
+	return strings.Join(parts, " ")
 }
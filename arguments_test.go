@@ -1,7 +1,10 @@
 package replyme
 
 import (
+	"errors"
 	"github.com/go-faker/faker/v4"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -24,3 +27,168 @@ func TestArgument(t *testing.T) {
 		}
 	})
 }
+
+func TestAssignArguments(t *testing.T) {
+	args := Arguments{
+		&Argument{Name: "src", Variadic: true},
+		&Argument{Name: "dst"},
+		&Argument{Name: "mode", Optional: true},
+	}
+
+	tests := []struct {
+		values  []string
+		want    []ASTArgument
+		surplus []string
+	}{
+		{
+			[]string{"a", "b"},
+			[]ASTArgument{{"src", "a"}, {"dst", "b"}},
+			nil,
+		},
+		{
+			[]string{"a", "b", "c", "d"},
+			[]ASTArgument{{"src", "a"}, {"src", "b"}, {"src", "c"}, {"dst", "d"}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got, surplus := assignArguments(args, tt.values)
+		if !reflect.DeepEqual(got, tt.want) || len(surplus) != len(tt.surplus) {
+			t.Errorf("assignArguments(%v) = %v, %v, want %v, %v", tt.values, got, surplus, tt.want, tt.surplus)
+		}
+	}
+
+	got, surplus := assignArguments(Arguments{&Argument{Name: "a"}, &Argument{Name: "b", Optional: true}}, []string{"1", "2", "3"})
+	if !reflect.DeepEqual(got, []ASTArgument{{"a", "1"}, {"b", "2"}}) || !reflect.DeepEqual(surplus, []string{"3"}) {
+		t.Errorf("got %v, %v, want the optional argument filled and 3 left over", got, surplus)
+	}
+
+	if usage := argumentsUsage(args); usage != "<src...> <dst> [mode]" {
+		t.Errorf("got usage %q", usage)
+	}
+}
+
+func TestArgumentValue(t *testing.T) {
+	port := &ArgumentValue[int]{Name: "port", Optional: true, Default: 8080}
+	hosts := &ArgumentValue[string]{
+		Name:     "hosts",
+		Optional: true,
+		Variadic: true,
+		Validate: func(value string) error {
+			if strings.Contains(value, "/") {
+				return errors.New("must not contain a slash")
+			}
+
+			return nil
+		},
+	}
+
+	app := &App{Commands: Commands{{Name: "serve", TypedArguments: Arguments{port, hosts}}}}
+
+	ast, err := app.parse("serve 9000 a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, _, _, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := createPreContext(flow[0], ast)
+	if got := MustGetArg[int](ctx, "port"); got != 9000 {
+		t.Errorf("got port %d, want 9000", got)
+	}
+
+	if got, ok := GetArg[[]string](ctx, "hosts"); !ok || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got hosts %v, want [a b]", got)
+	}
+
+	appRunCleaner(app)
+
+	ast, err = app.parse("serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if flow, _, _, err = createCommandFlow(app, ast); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := MustGetArg[int](createPreContext(flow[0], ast), "port"); got != 8080 {
+		t.Errorf("got port %d, want the default 8080", got)
+	}
+
+	appRunCleaner(app)
+
+	for _, input := range []string{"serve abc", "serve 1 a/b"} {
+		ast, err = app.parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, _, err = createCommandFlow(app, ast)
+		if !errors.Is(err, ErrorInvalidArgument) {
+			t.Errorf("%s: got %v, want an invalid argument error", input, err)
+		}
+
+		appRunCleaner(app)
+	}
+}
+
+func TestCommand_Arguments(t *testing.T) {
+	app := &App{Commands: Commands{{
+		Name:           "copy",
+		Arguments:      []*Argument{{Name: "src"}},
+		TypedArguments: Arguments{&ArgumentValue[int]{Name: "mode", Optional: true}},
+	}}}
+
+	if usage := argumentsUsage(app.Commands[0].arguments()); usage != "<src> [mode]" {
+		t.Errorf("got usage %q, want the typed arguments after the Arguments", usage)
+	}
+
+	ast, err := app.parse("copy a.txt 644")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, _, _, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := createPreContext(flow[0], ast)
+	if src, mode := MustGetArg[string](ctx, "src"), MustGetArg[int](ctx, "mode"); src != "a.txt" || mode != 644 {
+		t.Errorf("got src %q and mode %d, want a.txt and 644", src, mode)
+	}
+}
+
+func TestArgument_GetValueInAction(t *testing.T) {
+	name := &Argument{Name: "name"}
+	greeting := &FlagValue[string]{Name: "greeting"}
+
+	var got []string
+
+	app := &App{Commands: Commands{{
+		Name:      "greet",
+		Flags:     Flags{greeting},
+		Arguments: []*Argument{name},
+		Action: func(ctx *Context) error {
+			got = append(got, greeting.Value()+" "+name.GetValue())
+
+			return nil
+		},
+	}}}
+
+	for _, command := range []string{"greet --greeting hello alice", "greet bob"} {
+		err := fullRunCommand(fullRunCommandParams{command, app, nil, nil, nil, nil, func(logMsg) {}, nil, nil, false, nil, nil, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := []string{"hello alice", " bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
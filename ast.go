@@ -18,9 +18,11 @@ type ASTArgument struct {
 
 // ASTNode - is a structure that defines the storage of a command after its successful parsing.
 type ASTNode struct {
-	Command      string
-	FullCommand  string
-	CommandTree  []string
+	Command     string
+	FullCommand string
+	CommandTree []string
+	// Path of the command that is run, e.g. `users/list`. Flags are keyed by the paths of the commands
+	CommandPath  string
 	Subcommands  []string
 	Arguments    []ASTArgument
 	Flags        map[string]map[string][]ASTFlag
@@ -0,0 +1,246 @@
+package replyme
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fieldSpec is a flag or an argument described by the tags of a struct field.
+//
+//	Env    string   `flag:"env,e" usage:"Target environment" default:"dev" required:"true" env:"DEPLOY_ENV"`
+//	Region string   `flag:"region" choices:"eu,us"`
+//	Target string   `arg:"target" usage:"What to deploy"`
+//	Files  []string `arg:"files" optional:"true"`
+type fieldSpec struct {
+	index        int
+	name         string
+	alias        string
+	usage        string
+	defaultValue string
+	hasDefault   bool
+	required     bool
+	optional     bool
+	envVars      []string
+	choices      []string
+}
+
+// boundField is a struct field with the flag or the argument it is read from.
+type boundField struct {
+	index int
+	flag  FlagDefinition
+	arg   Arg
+}
+
+// CommandFor creates a command whose flags and arguments are derived from the tagged fields of T,
+// and whose Action receives T filled with their values.
+//
+// A field with the `flag:"name,alias"` tag becomes a flag and a field with the `arg:"name"` tag
+// becomes a positional argument; an empty name is derived from the field name, e.g. DryRun is dry-run.
+// The `usage`, `default`, `required`, `env` and `choices` tags configure flags, and `usage`, `default`
+// and `optional` configure arguments. A slice argument is variadic if its element type is registered.
+// The field types must be registered in the flag type registry, see RegisterFlagType.
+//
+// The flags and arguments are added after the ones already in command, so Before, OnEnd, Subcommands
+// and any hand-written flags keep working. Required flags and choices are checked before Before runs.
+// CommandFor panics if a field has an unregistered type or an invalid tag, because that is a bug in the program.
+func CommandFor[T any](command Command, action func(ctx *Context, opts *T) error) *Command {
+	fields, err := bindFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		panic(err)
+	}
+
+	for _, field := range fields {
+		if field.flag != nil {
+			command.Flags = append(command.Flags, field.flag)
+		} else {
+			command.TypedArguments = append(command.TypedArguments, field.arg)
+		}
+	}
+
+	command.Action = func(ctx *Context) error {
+		opts := new(T)
+		fillFields(ctx, reflect.ValueOf(opts).Elem(), fields)
+
+		return action(ctx, opts)
+	}
+
+	return &command
+}
+
+// bindFields creates the flags and arguments described by the tags of the struct type t.
+func bindFields(t reflect.Type) ([]boundField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, newErrorInvalidStructTag(t.String(), "CommandFor needs a struct type")
+	}
+
+	var fields []boundField
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		field, isFlag, ok, err := parseFieldTags(sf)
+		if err != nil {
+			return nil, newErrorInvalidStructTag(t.String()+"."+sf.Name, err.Error())
+		}
+
+		if !ok {
+			continue
+		}
+
+		field.index = i
+
+		bound, err := newBoundField(sf.Type, field, isFlag)
+		if err != nil {
+			return nil, newErrorInvalidStructTag(t.String()+"."+sf.Name, err.Error())
+		}
+
+		fields = append(fields, bound)
+	}
+
+	return fields, nil
+}
+
+// parseFieldTags reads the tags of the field. ok is false if the field is neither a flag nor an argument.
+func parseFieldTags(sf reflect.StructField) (field fieldSpec, isFlag, ok bool, err error) {
+	flagTag, isFlag := sf.Tag.Lookup("flag")
+	argTag, isArg := sf.Tag.Lookup("arg")
+
+	switch {
+	case isFlag && isArg:
+		return field, false, false, errors.New("a field cannot be both a flag and an argument")
+	case isFlag:
+		name, alias, _ := strings.Cut(flagTag, ",")
+		field.name, field.alias = name, alias
+	case isArg:
+		field.name = argTag
+	default:
+		return field, false, false, nil
+	}
+
+	if field.name == "" {
+		field.name = kebabCase(sf.Name)
+	}
+
+	field.usage = sf.Tag.Get("usage")
+	field.defaultValue, field.hasDefault = sf.Tag.Lookup("default")
+
+	if field.required, err = boolTag(sf, "required"); err != nil {
+		return field, false, false, err
+	}
+
+	if field.optional, err = boolTag(sf, "optional"); err != nil {
+		return field, false, false, err
+	}
+
+	if env := sf.Tag.Get("env"); env != "" {
+		field.envVars = splitList(env)
+	}
+
+	if choices := sf.Tag.Get("choices"); choices != "" {
+		field.choices = splitList(choices)
+	}
+
+	return field, isFlag, true, nil
+}
+
+func boolTag(sf reflect.StructField, key string) (bool, error) {
+	value, ok := sf.Tag.Lookup(key)
+	if !ok {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, newErrorInvalidFlagValue(value, "bool")
+	}
+
+	return b, nil
+}
+
+// newBoundField creates the flag or the argument of the field type from the flag type registry.
+func newBoundField(t reflect.Type, field fieldSpec, isFlag bool) (boundField, error) {
+	bound := boundField{index: field.index}
+
+	if isFlag {
+		info := flagTypes.lookup(t)
+		if info == nil {
+			return bound, newErrorUnknownFlagType(t.String())
+		}
+
+		flag, err := info.newFlag(field)
+		bound.flag = flag
+
+		return bound, err
+	}
+
+	variadic := false
+
+	info := flagTypes.lookup(t)
+	if t.Kind() == reflect.Slice {
+		if elem := flagTypes.lookup(t.Elem()); elem != nil {
+			info, variadic = elem, true
+		}
+	}
+
+	if info == nil {
+		return bound, newErrorUnknownFlagType(t.String())
+	}
+
+	arg, err := info.newArg(field, variadic)
+	bound.arg = arg
+
+	return bound, err
+}
+
+// fillFields sets the fields of the struct value to the values their flags and arguments have in the run of ctx.
+// Fields whose flag or argument has no value keep their zero value.
+func fillFields(ctx *Context, v reflect.Value, fields []boundField) {
+	flags, args := ctx.getFlags(), ctx.command.arguments()
+
+	for _, field := range fields {
+		var (
+			value interface{}
+			err   error
+		)
+
+		if field.flag != nil {
+			if flag := findFlag(flags, field.flag.GetName()); flag != nil {
+				value, err = flag.ParsedValue()
+			}
+		} else if arg := findArg(args, field.arg.GetName()); arg != nil {
+			value, err = arg.ParsedValue()
+		}
+
+		if err != nil || value == nil {
+			continue
+		}
+
+		v.Field(field.index).Set(reflect.ValueOf(value))
+	}
+}
+
+// kebabCase converts a field name to a flag name, e.g. DryRun to dry-run.
+func kebabCase(name string) string {
+	var b strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('-')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
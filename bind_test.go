@@ -0,0 +1,119 @@
+package replyme
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type deployOpts struct {
+	Env     string        `flag:"env,e" usage:"Target environment" default:"dev" choices:"dev,prod"`
+	Token   string        `flag:"" required:"true"`
+	DryRun  bool          `flag:""`
+	Timeout time.Duration `flag:"timeout" default:"30s"`
+	Target  string        `arg:"target"`
+	Files   []string      `arg:"files" optional:"true"`
+	ignored string
+	Other   int
+}
+
+func TestCommandFor(t *testing.T) {
+	var got *deployOpts
+
+	before := false
+	command := CommandFor[deployOpts](Command{
+		Name: "deploy",
+		Before: func(ctx *Context) (bool, error) {
+			before = true
+
+			return true, nil
+		},
+	}, func(ctx *Context, opts *deployOpts) error {
+		got = opts
+
+		return nil
+	})
+
+	names := make([]string, len(command.Flags))
+	for i, flag := range command.Flags {
+		names[i] = flag.GetName()
+	}
+
+	if !reflect.DeepEqual(names, []string{"env", "token", "dry-run", "timeout"}) {
+		t.Fatalf("got flags %v", names)
+	}
+
+	if usage := argumentsUsage(command.arguments()); usage != "<target> [files...]" {
+		t.Fatalf("got arguments %q", usage)
+	}
+
+	app := &App{Name: "test", Commands: Commands{command}}
+
+	err := runCommand(app, &Context{}, "deploy -e prod --token=abc --dry-run api a.yaml b.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &deployOpts{
+		Env:     "prod",
+		Token:   "abc",
+		DryRun:  true,
+		Timeout: 30 * time.Second,
+		Target:  "api",
+		Files:   []string{"a.yaml", "b.yaml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	appRunCleaner(app)
+
+	before = false
+
+	err = runCommand(app, &Context{}, "deploy --token=abc --env=staging api")
+	if !errors.Is(err, ErrorInvalidFlagChoice) || before {
+		t.Errorf("got %v, before ran: %v, want a choice error before Before", err, before)
+	}
+
+	appRunCleaner(app)
+
+	err = runCommand(app, &Context{}, "deploy api")
+	if !errors.Is(err, ErrorRequiredFlag) || before {
+		t.Errorf("got %v, before ran: %v, want a required flag error before Before", err, before)
+	}
+}
+
+func TestCommandFor_InvalidTags(t *testing.T) {
+	type unknownType struct {
+		Value complex64 `flag:"value"`
+	}
+
+	type badDefault struct {
+		Port int `flag:"port" default:"eighty"`
+	}
+
+	for name, create := range map[string]func(){
+		"unknown type": func() { CommandFor[unknownType](Command{}, nil) },
+		"bad default":  func() { CommandFor[badDefault](Command{}, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrorInvalidStructTag) {
+					t.Errorf("got panic %v, want an invalid struct tag error", err)
+				}
+			}()
+
+			create()
+		})
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, want := range map[string]string{"DryRun": "dry-run", "Env": "env", "HTTPPort": "http-port", "URL": "url"} {
+		if got := kebabCase(in); got != want {
+			t.Errorf("kebabCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
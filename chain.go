@@ -0,0 +1,47 @@
+package replyme
+
+// chainOperator is the operator before a statement of a chain, deciding whether it runs.
+type chainOperator uint8
+
+const (
+	// chainSequence is before the first statement, which always runs.
+	chainSequence chainOperator = iota
+	// chainAnd is `&&`: the statement runs if the previous one succeeded.
+	chainAnd
+	// chainOr is `||`: the statement runs if the previous one failed.
+	chainOr
+)
+
+func (o chainOperator) String() string {
+	switch o {
+	case chainAnd:
+		return "&&"
+	case chainOr:
+		return "||"
+	default:
+		return ";"
+	}
+}
+
+// chainSegment is a statement of a chain with the operator before it.
+type chainSegment struct {
+	op   chainOperator
+	stmt statement
+}
+
+// runChain runs the statements of the chain with shell semantics: `a && b` runs b if a succeeded,
+// and `a || b` runs b if a failed. A statement that is skipped does not change the result,
+// so `a && b || c` runs c if a or b failed. It returns the error of the last statement that ran.
+func runChain(segments []chainSegment, run func(s statement) error) error {
+	var err error
+
+	for _, segment := range segments {
+		if (segment.op == chainAnd && err != nil) || (segment.op == chainOr && err == nil) {
+			continue
+		}
+
+		err = run(segment.stmt)
+	}
+
+	return err
+}
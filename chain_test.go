@@ -0,0 +1,87 @@
+package replyme
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseChain(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"build", []string{"build"}},
+		{"build && deploy --env stage || notify failed", []string{"build && deploy --env stage || notify failed"}},
+		{"a; b;", []string{"a", "b"}},
+		{`echo "a && b" 'c;d' e\;f`, []string{`echo "a && b" 'c;d' e\;f`}},
+		{"a&&b||c", []string{"a && b || c"}},
+		{"a & b | c", []string{"a &", "b | c"}},
+		{`echo 'a & b' a\&b`, []string{`echo 'a & b' a\&b`}},
+		{"echo $(a && b; c) && d", []string{"echo $(a && b; c) && d"}},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		got := make([]string, len(program))
+		for i, s := range program {
+			got[i] = s.String()
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseProgram(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"&& a", "a &&", "a ||", "; a", "a;; b", "a && || b", "& a", "a & ; b", "a && & b"} {
+		if _, err := parseProgram(input); !errors.Is(err, ErrorChainSyntax) {
+			t.Errorf("parseProgram(%q): got %v, want a syntax error", input, err)
+		}
+	}
+}
+
+func TestRunChain(t *testing.T) {
+	failure := errors.New("failed")
+
+	tests := []struct {
+		input string
+		fail  map[string]bool
+		ran   []string
+		err   error
+	}{
+		{"a && b || c", nil, []string{"a", "b"}, nil},
+		{"a && b || c", map[string]bool{"a": true}, []string{"a", "c"}, nil},
+		{"a && b || c", map[string]bool{"b": true, "c": true}, []string{"a", "b", "c"}, failure},
+		{"a || b && c", nil, []string{"a", "c"}, nil},
+		{"a; b", map[string]bool{"a": true}, []string{"a", "b"}, nil},
+		{"a && b; c", map[string]bool{"a": true}, []string{"a", "c"}, nil},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ran []string
+
+		in := &interpreter{run: func(p fullRunCommandParams) error {
+			ran = append(ran, p.command)
+			if tt.fail[p.command] {
+				return failure
+			}
+
+			return nil
+		}}
+
+		err = in.execList(fullRunCommandParams{}, program)
+
+		if !reflect.DeepEqual(ran, tt.ran) || !errors.Is(err, tt.err) {
+			t.Errorf("%s with failures %v: ran %v, %v, want %v, %v", tt.input, tt.fail, ran, err, tt.ran, tt.err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"os"
 	"strings"
+	"time"
 )
 
 func cliRunSelectOne(t TUIRequest, c chan<- bool) {
@@ -104,7 +105,11 @@ func runCLITUI(t TUIRequest, c chan<- bool) {
 }
 
 func cliRunner(app *App) error {
-	cmd := strings.Join(os.Args[1:], " ")
+	if len(os.Args) > 1 && os.Args[1] == completeCommandName {
+		return runCompleteRequest(app, os.Args[2:], os.Stdout)
+	}
+
+	cmd := cliCommandLine(os.Args[1:], app.CLIPipelines)
 	logsChan := make(chan log)
 
 	go func() {
@@ -133,7 +138,7 @@ func cliRunner(app *App) error {
 	}
 
 	err := fullRunCommand(fullRunCommandParams{
-		cmd, app, logsChan, os.Stdout, os.Stderr, func(msg logMsg) {
+		cmd, app, logsChan, os.Stdin, os.Stdout, os.Stderr, func(msg logMsg) {
 			logsChan <- log{
 				logTypeLog,
 				cmd,
@@ -141,8 +146,38 @@ func cliRunner(app *App) error {
 				nil,
 				msg.Time,
 			}
-		}, nil, runCLITUI, true,
+		}, nil, runCLITUI, true, nil, nil, 0,
 	})
 
+	// The output of `completion` is read by the shell, so it is printed without the status line
+	if err == nil && os.Args[1] != completionCommandName {
+		logsChan <- log{
+			logTypeCommandSuccess,
+			cmd,
+			cmd,
+			nil,
+			time.Now(),
+		}
+	}
+
+	if hint := renderSuggestions(errorSuggestions(err)); hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+
 	return err
 }
+
+// cliCommandLine joins the arguments of the program into a command line. The arguments are quoted
+// if needed. If pipelines is set, `|`, `<`, `>` and `>>` are not, so that they become pipeline operators.
+func cliCommandLine(args []string, pipelines bool) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if pipelines && isPipelineOperator(arg) {
+			words[i] = arg
+		} else {
+			words[i] = quoteWord(arg)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -2,6 +2,8 @@ package replyme
 
 import (
 	"golang.org/x/exp/slices"
+	"strings"
+	"time"
 )
 
 // Command - the structure for creating your command.
@@ -16,57 +18,123 @@ type Command struct {
 	Subcommands Commands
 	// Flags for executing your command
 	Flags Flags
+	// Flags that are accepted by this command and all of its subcommands, at any depth
+	PersistentFlags Flags
 	// Arguments for executing your command
 	Arguments []*Argument
+	// Typed arguments, like `ArgumentValue[T]` or your own Arg implementations. They follow the Arguments
+	TypedArguments Arguments
+	// Groups of flags of which at most one can be set, e.g. {{"file", "url"}}
+	MutuallyExclusive [][]string
+	// Groups of flags that must be set together or not at all, e.g. {{"user", "password"}}
+	RequiredTogether [][]string
+	// Groups of flags of which at least one must be set
+	OneRequired [][]string
+	// Unknown flags and surplus arguments are passed to the command as positional arguments
+	// instead of being rejected, e.g. for commands that wrap other programs. See Context.Args
+	PassThroughArgs bool
+	// The command reads Context.Stdin, so its input can come from a pipe `|` or a file `<`.
+	// Other commands cannot be used after a pipe
+	ReadsStdin bool
+	// How long the command can run before its Context is cancelled and it fails with TimeoutError.
+	// Subcommands without a Timeout use the one of their parent, and App.DefaultTimeout is used if no command has one.
+	// It is overridden with the --timeout flag
+	Timeout time.Duration
 	// The function that is executed before executing the main function Action
 	Before func(ctx *Context) (bool, error)
 	// The main function of the command
 	Action func(ctx *Context) error
 	// A function that runs after the main Action function has successfully completed its action
 	OnEnd func(ctx *Context) error
+	// The command waits for other commands, like `wait` and `source`, so App.DefaultTimeout does not apply to it
+	waitsForCommands bool
+}
+
+// arguments returns the positional arguments of the command: the Arguments, then the TypedArguments.
+func (c *Command) arguments() Arguments {
+	args := make(Arguments, 0, len(c.Arguments)+len(c.TypedArguments))
+	for _, arg := range c.Arguments {
+		args = append(args, arg)
+	}
+
+	return append(args, c.TypedArguments...)
 }
 
 // Commands is an abbreviation for the type `[]*replyme.Command`.
 type Commands []*Command
 
-func (c Commands) getCommand(name string) (*Command, error) {
-	commandsArr := c.getCommandsArray()
-	i := slices.IndexFunc(commandsArr, func(command *Command) bool {
-		return command.Name == name
-	})
+// getCommandPath returns the commands along the command tree, from the top-level command to the one that is run.
+func (c Commands) getCommandPath(tree []string) ([]*Command, error) {
+	path := make([]*Command, 0, len(tree))
+	commands := c
 
-	if i == -1 {
-		return nil, newErrorUnknownCommand(name)
+	for i, name := range tree {
+		j := slices.IndexFunc(commands, func(command *Command) bool {
+			return command.Name == name
+		})
+		if j == -1 {
+			if i == 0 {
+				return nil, newErrorUnknownCommand(name)
+			}
+
+			return nil, newErrorSubcommandUnknown(commandPath(tree[:i+1]))
+		}
+
+		path = append(path, commands[j])
+		commands = commands[j].Subcommands
 	}
 
-	return commandsArr[i], nil
+	return path, nil
 }
 
-func subber(commands *Command) []*Command {
-	s := make([]*Command, 0)
+// validateCommands reports the commands that cannot be told apart:
+// siblings with the same name, or with a name or alias that is also used by another sibling.
+func validateCommands(commands Commands, parent []string, inherited Flags, opts parseOptions) error {
+	seen := map[string]bool{}
 
-	if commands.Subcommands != nil {
-		for _, subcommand := range commands.Subcommands {
-			if subcommand.Subcommands != nil {
-				s = append(s, subber(subcommand)...)
+	for _, command := range commands {
+		for _, name := range append([]string{command.Name}, command.Aliases...) {
+			key := name
+			if opts.CaseInsensitive {
+				key = strings.ToLower(name)
 			}
 
-			s = append(s, subcommand)
+			if seen[key] {
+				return newErrorAmbiguousCommand(ambiguityScope(parent), name)
+			}
+
+			seen[key] = true
+		}
+
+		args := command.arguments()
+
+		variadic := slices.IndexFunc(args, Arg.IsVariadic)
+		if variadic != -1 && slices.IndexFunc(args[variadic+1:], Arg.IsVariadic) != -1 {
+			return newErrorInvalidArguments(commandPath(append(slices.Clone(parent), command.Name)),
+				L(i18n_arguments_one_variadic))
+		}
+
+		err := validateFlagGroups(command, parent, append(slices.Clone(command.Flags), inherited...))
+		if err != nil {
+			return err
+		}
+
+		err = validateCommands(command.Subcommands, append(slices.Clone(parent), command.Name),
+			append(slices.Clone(inherited), command.PersistentFlags...), opts)
+		if err != nil {
+			return err
 		}
 	}
 
-	return s
+	return nil
 }
 
-func (c Commands) getCommandsArray() []*Command {
-	commands := make([]*Command, 0)
-	commands = append(commands, c...)
-
-	for _, command := range c {
-		commands = append(commands, subber(command)...)
+func ambiguityScope(parent []string) string {
+	if len(parent) == 0 {
+		return L(i18n_commands_top_level)
 	}
 
-	return commands
+	return commandPath(parent)
 }
 
 func (c Commands) mustGetCommand(name string) *Command {
@@ -79,3 +147,18 @@ func (c Commands) mustGetCommand(name string) *Command {
 
 	return c[i]
 }
+
+// declaresFlag returns whether the command itself declares a flag with the same name or alias.
+func (c *Command) declaresFlag(flag FlagDefinition) bool {
+	for _, flags := range []Flags{c.Flags, c.PersistentFlags} {
+		if findFlag(flags, flag.GetName()) != nil {
+			return true
+		}
+
+		if flag.GetAlias() != "" && findFlag(flags, flag.GetAlias()) != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,11 +1,13 @@
 package replyme
 
 import (
+	"errors"
 	"github.com/go-faker/faker/v4"
+	"strings"
 	"testing"
 )
 
-func TestCommands_GetCommand(t *testing.T) {
+func TestCommands_MustGetCommand(t *testing.T) {
 	testCmd := faker.Word()
 	cmd := Commands{
 		{
@@ -21,196 +23,36 @@ func TestCommands_GetCommand(t *testing.T) {
 			Usage: faker.Sentence(),
 		},
 	}
-	command, err := cmd.getCommand(testCmd)
-
-	if err != nil {
-		t.Fatal(err)
-	}
+	command := cmd.mustGetCommand(testCmd)
 
 	if command.Name != testCmd {
 		t.Errorf("got command name %q, want %q", command.Name, testCmd)
 	}
 }
 
-func TestSubber(t *testing.T) {
-	testCmd := faker.Word()
-	cmd := &Command{
-		Name:  faker.Word(),
-		Usage: faker.Sentence(),
-		Subcommands: Commands{
-			{
-				Name:  faker.Word(),
-				Usage: faker.Sentence(),
-				Subcommands: Commands{
-					{
-						Name:  faker.Word(),
-						Usage: faker.Sentence(),
-					},
-					{
-						Name:  faker.Word(),
-						Usage: faker.Sentence(),
-						Subcommands: Commands{
-							{
-								Name:  faker.Word(),
-								Usage: faker.Sentence(),
-							},
-						},
-					},
-				},
-			},
-			{
-				Name:  testCmd,
-				Usage: faker.Sentence(),
-			},
-			{
-				Name:  faker.Word(),
-				Usage: faker.Sentence(),
-			},
-		},
+func TestValidateCommands(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
 	}
 
-	arr := subber(cmd)
-	if len(arr) != 6 {
-		t.Errorf("got subber length %d, want 6", len(arr))
+	commands := Commands{
+		{Name: "users", Subcommands: Commands{{Name: "list"}, {Name: "add"}}},
+		{Name: "groups", Subcommands: Commands{{Name: "list"}}},
 	}
-}
 
-var testObj = func(testCmd string) Commands {
-	return Commands{
-		&Command{
-			Name:  faker.Word(),
-			Usage: faker.Sentence(),
-			Subcommands: Commands{
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-					Subcommands: Commands{
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-						},
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-							Subcommands: Commands{
-								{
-									Name:  faker.Word(),
-									Usage: faker.Sentence(),
-								},
-							},
-						},
-					},
-				},
-				{
-					Name:  testCmd,
-					Usage: faker.Sentence(),
-				},
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-				},
-			},
-		},
-		&Command{
-			Name:  faker.Word(),
-			Usage: faker.Sentence(),
-			Subcommands: Commands{
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-					Subcommands: Commands{
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-						},
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-							Subcommands: Commands{
-								{
-									Name:  faker.Word(),
-									Usage: faker.Sentence(),
-								},
-							},
-						},
-					},
-				},
-				{
-					Name:  testCmd,
-					Usage: faker.Sentence(),
-				},
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-				},
-			},
-		},
-		&Command{
-			Name:  faker.Word(),
-			Usage: faker.Sentence(),
-			Subcommands: Commands{
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-					Subcommands: Commands{
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-						},
-						{
-							Name:  faker.Word(),
-							Usage: faker.Sentence(),
-							Subcommands: Commands{
-								{
-									Name:  faker.Word(),
-									Usage: faker.Sentence(),
-								},
-							},
-						},
-					},
-				},
-				{
-					Name:  testCmd,
-					Usage: faker.Sentence(),
-				},
-				{
-					Name:  faker.Word(),
-					Usage: faker.Sentence(),
-				},
-			},
-		},
+	if err := validateCommands(commands, nil, nil, parseOptions{}); err != nil {
+		t.Fatalf("same-named subcommands of different parents are not ambiguous: %v", err)
 	}
-}
 
-func TestCommands_GetCommandsArray(t *testing.T) {
-	testCmd := faker.Word()
-	cmd := testObj(testCmd)
+	commands[0].Subcommands = append(commands[0].Subcommands, &Command{Name: "remove", Aliases: []string{"add"}})
 
-	arr := cmd.getCommandsArray()
-	if len(arr) != 21 {
-		t.Errorf("got subber length %d, want 21", len(arr))
+	err := validateCommands(commands, nil, nil, parseOptions{})
+	if !errors.Is(err, ErrorAmbiguousCommand) || !strings.Contains(err.Error(), "users") {
+		t.Fatalf("got %v, want an ambiguous command error for users", err)
 	}
-}
 
-func TestCommands_MustGetCommand(t *testing.T) {
-	testCmd := faker.Word()
-	cmd := Commands{
-		{
-			Name:  faker.Word(),
-			Usage: faker.Sentence(),
-		},
-		{
-			Name:  testCmd,
-			Usage: faker.Sentence(),
-		},
-		{
-			Name:  faker.Word(),
-			Usage: faker.Sentence(),
-		},
-	}
-	command := cmd.mustGetCommand(testCmd)
-
-	if command.Name != testCmd {
-		t.Errorf("got command name %q, want %q", command.Name, testCmd)
+	err = validateCommands(Commands{{Name: "users"}, {Name: "groups", Aliases: []string{"users"}}}, nil, nil, parseOptions{})
+	if !errors.Is(err, ErrorAmbiguousCommand) || !strings.Contains(err.Error(), L(i18n_commands_top_level)) {
+		t.Fatalf("got %v, want an ambiguous command error for the top-level commands", err)
 	}
 }
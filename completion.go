@@ -0,0 +1,337 @@
+package replyme
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// completion - the result of completing the word under the cursor.
+type completion struct {
+	// Candidates that can replace the word under the cursor
+	Candidates []string
+	// The word under the cursor that is being completed
+	Partial string
+	// Position (in runes) where the word under the cursor starts
+	Start int
+}
+
+// completionWalker - the state of the command line before the word that is being completed.
+type completionWalker struct {
+	command  *Command
+	tree     []string
+	argIndex int
+	// The first word is not a known command
+	unknown bool
+	// The flag that is waiting for its value
+	pendingFlag FlagDefinition
+	// The persistent flags of the command and its parents, and the global flags
+	inherited Flags
+	// The session of the application, which the completers can read with Context.Get
+	session *session
+}
+
+func (a *App) complete(line string) completion {
+	words, partial, start := splitCompletionWords(line)
+	c := a.completeWords(words, partial)
+	c.Start = start
+
+	return c
+}
+
+func (a *App) completeWords(words []string, partial string) completion {
+	w := a.walkCompletion(words)
+	line := strings.Join(append(slices.Clone(words), partial), " ")
+
+	return completion{
+		Candidates: w.candidates(line, partial, a.Commands),
+		Partial:    partial,
+	}
+}
+
+func (a *App) walkCompletion(words []string) completionWalker {
+	w := completionWalker{session: &a.session}
+
+	if len(words) == 0 {
+		return w
+	}
+
+	w.command = findCompletionCommand(a.Commands, words[0], a.parseOptions())
+	if w.command == nil {
+		w.unknown = true
+
+		return w
+	}
+
+	w.tree = append(w.tree, w.command.Name)
+	w.inherited = append(slices.Clone(a.GlobalFlags), w.command.PersistentFlags...)
+
+	for _, word := range words[1:] {
+		if w.pendingFlag != nil {
+			w.pendingFlag = nil
+
+			continue
+		}
+
+		if strings.HasPrefix(word, "-") {
+			if strings.Contains(word, "=") {
+				continue
+			}
+
+			flag := findFlag(w.flags(), strings.TrimLeft(word, "-"))
+			if flag != nil && kindOfFlag(flag).takesValue() {
+				w.pendingFlag = flag
+			}
+
+			continue
+		}
+
+		if w.argIndex == 0 {
+			if sub := findCompletionCommand(w.command.Subcommands, word, a.parseOptions()); sub != nil {
+				w.command = sub
+				w.tree = append(w.tree, sub.Name)
+				w.inherited = append(w.inherited, sub.PersistentFlags...)
+
+				continue
+			}
+		}
+
+		w.argIndex++
+	}
+
+	return w
+}
+
+func (w completionWalker) candidates(line, partial string, commands Commands) []string {
+	if w.unknown {
+		return nil
+	}
+
+	if w.command == nil {
+		return filterCompletions(commandCompletions(commands), partial)
+	}
+
+	ctx := w.context(line)
+
+	if w.pendingFlag != nil {
+		return quoteCompletions(filterCompletions(completeFlagValue(w.pendingFlag, ctx, partial), partial))
+	}
+
+	if strings.HasPrefix(partial, "-") {
+		if i := strings.Index(partial, "="); i != -1 {
+			flag := findFlag(w.flags(), strings.TrimLeft(partial[:i], "-"))
+			if flag == nil {
+				return nil
+			}
+
+			values := filterCompletions(completeFlagValue(flag, ctx, partial[i+1:]), partial[i+1:])
+			for j := range values {
+				values[j] = partial[:i+1] + quoteWord(values[j])
+			}
+
+			return values
+		}
+
+		return filterCompletions(flagCompletions(w.flags()), partial)
+	}
+
+	var result []string
+
+	if w.argIndex == 0 {
+		result = filterCompletions(commandCompletions(w.command.Subcommands), partial)
+	}
+
+	if arg := completionArgument(w.command.arguments(), w.argIndex); arg != nil {
+		// The values are matched against the partial word before they are quoted, like the flag values
+		result = append(result, quoteCompletions(filterCompletions(arg.Completions(ctx, partial), partial))...)
+	}
+
+	// An empty prefix matches every candidate, so this only removes the duplicates
+	return filterCompletions(result, "")
+}
+
+// completionArgument returns the argument at the specified position, the variadic argument
+// for all positions after it, or nil if the command takes fewer arguments.
+func completionArgument(args Arguments, i int) Arg {
+	for j, arg := range args {
+		if j == i || (arg.IsVariadic() && j < i) {
+			return arg
+		}
+	}
+
+	return nil
+}
+
+// flags returns the flags that can be given to the command, its own flags first.
+func (w completionWalker) flags() Flags {
+	return append(slices.Clone(w.command.Flags), w.inherited...)
+}
+
+func (w completionWalker) context(line string) *Context {
+	ctx := createPreContext(w.command, &ASTNode{
+		Command:     w.tree[0],
+		FullCommand: line,
+		CommandTree: w.tree,
+		Subcommands: w.tree[1:],
+	})
+	ctx.emitLog = func(logMsg) {}
+	ctx.session = w.session
+
+	return ctx
+}
+
+func findCompletionCommand(commands Commands, name string, opts parseOptions) *Command {
+	i, err := matchCommand(createCommandSchema(commands), name, opts)
+	if err != nil || i == -1 {
+		return nil
+	}
+
+	return commands[i]
+}
+
+func findFlag(flags Flags, name string) FlagDefinition {
+	for _, flag := range flags {
+		if flag.GetName() == name || (flag.GetAlias() != "" && flag.GetAlias() == name) {
+			return flag
+		}
+	}
+
+	return nil
+}
+
+func commandCompletions(commands Commands) []string {
+	result := make([]string, 0, len(commands))
+
+	for _, command := range commands {
+		result = append(result, command.Name)
+		result = append(result, command.Aliases...)
+	}
+
+	return result
+}
+
+func flagCompletions(flags Flags) []string {
+	result := make([]string, 0, len(flags))
+
+	for _, flag := range flags {
+		result = append(result, "--"+flag.GetName())
+
+		if flag.GetAlias() != "" {
+			result = append(result, "-"+flag.GetAlias())
+		}
+	}
+
+	return result
+}
+
+func filterCompletions(candidates []string, partial string) []string {
+	result := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(candidate, partial) {
+			continue
+		}
+
+		seen[candidate] = true
+		result = append(result, candidate)
+	}
+
+	return result
+}
+
+func quoteCompletions(candidates []string) []string {
+	result := make([]string, len(candidates))
+	for i := range candidates {
+		result[i] = quoteWord(candidates[i])
+	}
+
+	return result
+}
+
+// quoteWord quotes the word if it has spaces, quotes or operators, so that it is parsed back as one word.
+func quoteWord(s string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"'\|<>;&`, r)
+	}) {
+		return s
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// splitCompletionWords splits the last command of the line into completed words and the word under the cursor,
+// also returning the position where that word starts.
+// Unlike tokenize, it does not fail on unclosed quotes, since the user is still typing.
+func splitCompletionWords(line string) ([]string, string, int) {
+	var words []string
+
+	var current strings.Builder
+
+	var quoteChar rune
+
+	escape := false
+	start := 0
+	inWord := false
+
+	for i, r := range []rune(line) {
+		if !inWord && !unicode.IsSpace(r) {
+			inWord = true
+			start = i
+		}
+
+		switch {
+		case escape:
+			current.WriteRune(r)
+
+			escape = false
+		case r == '\\':
+			escape = true
+		case quoteChar != 0:
+			if r == quoteChar {
+				quoteChar = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quoteChar = r
+		case strings.ContainsRune(";&|", r):
+			// `;`, `&&`, `||`, `|` and `&` start a new command, whose words are the only ones completed
+			words = nil
+			current.Reset()
+
+			inWord = false
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+			}
+
+			inWord = false
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if !inWord {
+		start = len([]rune(line))
+	}
+
+	return words, current.String(), start
+}
+
+// commonPrefix returns the longest common prefix of all candidates.
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	prefix := []rune(candidates[0])
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return string(prefix)
+}
@@ -0,0 +1,174 @@
+package replyme
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newCompletionApp() *App {
+	return &App{
+		Commands: Commands{
+			{
+				Name:    "deploy",
+				Aliases: []string{"dep"},
+				Flags: Flags{
+					&FlagValue[string]{
+						Name:  "env",
+						Alias: "e",
+						Complete: func(ctx *Context, partial string) []string {
+							return []string{"dev", "stage", "prod"}
+						},
+					},
+					&FlagValue[bool]{
+						Name: "force",
+					},
+				},
+				Subcommands: Commands{
+					{
+						Name: "status",
+					},
+					{
+						Name: "start",
+						Arguments: []*Argument{
+							&Argument{
+								Name: "id",
+								Complete: func(ctx *Context, partial string) []string {
+									return []string{ctx.GetName() + "-1", ctx.GetName() + "-2"}
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "delete",
+				Arguments: []*Argument{
+					&Argument{
+						Name: "file",
+						Complete: func(ctx *Context, partial string) []string {
+							return []string{"my file", "notes.txt"}
+						},
+					},
+				},
+			},
+			{
+				Name: "users",
+			},
+		},
+	}
+}
+
+func TestApp_Complete(t *testing.T) {
+	app := newCompletionApp()
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{"deploy", "dep", "delete", "users"}},
+		{"de", []string{"deploy", "dep", "delete"}},
+		{"u", []string{"users"}},
+		{"unknown ", nil},
+		{"deploy ", []string{"status", "start"}},
+		{"dep st", []string{"status", "start"}},
+		{"deploy --", []string{"--env", "--force"}},
+		{"deploy -", []string{"--env", "-e", "--force"}},
+		{"deploy --env ", []string{"dev", "stage", "prod"}},
+		{"deploy -e p", []string{"prod"}},
+		{"deploy --env=s", []string{"--env=stage"}},
+		{"deploy --force st", []string{"status", "start"}},
+		{"deploy start ", []string{"start-1", "start-2"}},
+		{"deploy start start-1 ", []string{}},
+		{"delete my", []string{`"my file"`}},
+		{`delete "my f`, []string{`"my file"`}},
+		{"deploy --env prod; deploy --", []string{"--env", "--force"}},
+		{"users && dep", []string{"deploy", "dep"}},
+		{"deploy --force || deploy ", []string{"status", "start"}},
+		{"users|deploy -e p", []string{"prod"}},
+		{"deploy --env dev & deploy --env=s", []string{"--env=stage"}},
+	}
+
+	for _, tt := range tests {
+		got := app.complete(tt.line).Candidates
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("complete(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCompletionWords(t *testing.T) {
+	words, partial, start := splitCompletionWords(`deploy --env "my sta`)
+
+	if !reflect.DeepEqual(words, []string{"deploy", "--env"}) {
+		t.Errorf("got words %v", words)
+	}
+
+	if partial != "my sta" || start != 13 {
+		t.Errorf("got partial %q at %d, want %q at %d", partial, start, "my sta", 13)
+	}
+
+	words, _, _ = splitCompletionWords(`deploy ";" --e`)
+	if !reflect.DeepEqual(words, []string{"deploy", ";"}) {
+		t.Errorf("got words %v, want the quoted ; to be a word", words)
+	}
+
+	_, partial, start = splitCompletionWords("deploy ")
+	if partial != "" || start != 7 {
+		t.Errorf("got partial %q at %d, want empty at 7", partial, start)
+	}
+}
+
+func TestTerminalInput_Tab(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newCompletionApp()
+	input := newTerminalInput(app.complete)
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("us")})
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	if input.Value() != "users " {
+		t.Fatalf("got %q, want %q", input.Value(), "users ")
+	}
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("deploy st")})
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	if input.Value() != "deploy sta" || !input.completion.active {
+		t.Fatalf("got %q, want common prefix %q with the popup open", input.Value(), "deploy sta")
+	}
+
+	if input.GetLines() != 3 {
+		t.Fatalf("got %d lines, want 3", input.GetLines())
+	}
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if input.Value() != "deploy status" {
+		t.Fatalf("got %q, want %q", input.Value(), "deploy status")
+	}
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if input.Value() != "deploy start" {
+		t.Fatalf("got %q, want %q", input.Value(), "deploy start")
+	}
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if input.Value() != "deploy status" {
+		t.Fatalf("got %q, want %q", input.Value(), "deploy status")
+	}
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if input.completion.active || input.GetLines() != 1 {
+		t.Fatal("the popup must be closed after typing")
+	}
+}
@@ -0,0 +1,324 @@
+package replyme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configCommandName     = "config"
+	configShowCommandName = "show"
+)
+
+// configLayer is a loaded config file. Its top-level keys are the global flags,
+// and its tables are the commands, e.g. `[deploy.status]` for the status subcommand of deploy.
+type configLayer struct {
+	file   string
+	values map[string]interface{}
+}
+
+// configLayers are the config files of the application, in the order they are listed in App.ConfigFiles.
+type configLayers []configLayer
+
+// configSection is the table of a command in every config file, the last file first.
+type configSection []configLayer
+
+// loadConfigFiles reads the config files. Files that do not exist are skipped,
+// so that a list can include optional files, e.g. a system-wide and a per-user one.
+func loadConfigFiles(files []string) (configLayers, error) {
+	layers := make(configLayers, 0, len(files))
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, newErrorConfigFile(file, err)
+		}
+
+		values, err := parseConfigFile(file, data)
+		if err != nil {
+			return nil, newErrorConfigFile(file, err)
+		}
+
+		layers = append(layers, configLayer{file: file, values: values})
+	}
+
+	return layers, nil
+}
+
+// parseConfigFile decodes a TOML, JSON or YAML file, depending on its extension.
+func parseConfigFile(file string, data []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	var err error
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	default:
+		err = fmt.Errorf("unsupported format %q, use .toml, .json or .yaml", filepath.Ext(file))
+	}
+
+	return values, err
+}
+
+// section returns the table of the command with the specified path in every file. Nil path is the top level.
+func (c configLayers) section(path []string) configSection {
+	var section configSection
+
+	for i := len(c) - 1; i >= 0; i-- {
+		table := c[i].values
+
+		for _, name := range path {
+			table, _ = table[name].(map[string]interface{})
+		}
+
+		if table != nil {
+			section = append(section, configLayer{file: c[i].file, values: table})
+		}
+	}
+
+	return section
+}
+
+// lookup returns the value of the flag in the section and the file it comes from.
+func (s configSection) lookup(flag FlagDefinition) (value, file string, ok bool) {
+	for _, layer := range s {
+		v, found := layer.values[flag.GetName()]
+		if !found {
+			continue
+		}
+
+		// A table with the name of the flag is a subcommand
+		if _, isTable := v.(map[string]interface{}); isTable {
+			continue
+		}
+
+		return configValueString(v), layer.file, true
+	}
+
+	return "", "", false
+}
+
+// configValueString formats a config value the way it is written on the command line.
+// Arrays become comma-separated lists.
+func configValueString(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case float64:
+		// JSON has only floats, so that 1000000 is written as is rather than as 1e+06
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i := range t {
+			parts[i] = configValueString(t[i])
+		}
+
+		return strings.Join(parts, ",")
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		return formatFlagValue(v)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// flagSourceKind describes where the value of a flag comes from.
+type flagSourceKind uint8
+
+const (
+	flagSourceUnset flagSourceKind = iota
+	flagSourceDefault
+	flagSourceConfig
+	flagSourceEnv
+)
+
+// flagSource is where the value of a flag that was not given on the command line comes from:
+// the name of the environment variable or the config file.
+type flagSource struct {
+	kind flagSourceKind
+	name string
+}
+
+func (s flagSource) String() string {
+	switch s.kind {
+	case flagSourceEnv:
+		return fmt.Sprintf(L(i18n_config_source_env), s.name)
+	case flagSourceConfig:
+		return fmt.Sprintf(L(i18n_config_source_file), s.name)
+	case flagSourceDefault:
+		return L(i18n_config_source_default)
+	default:
+		return L(i18n_config_source_unset)
+	}
+}
+
+// lookupFlagSource returns the value of a flag that was not given on the command line:
+// from its environment variables first, then from the config files. ok is false if it is in neither.
+// The --timeout added by replyme is only read from the command line, so that it does not take
+// a `timeout` key of the command, which can be written differently, e.g. as a number of seconds.
+func lookupFlagSource(flag FlagDefinition, envPrefix string, section configSection) (value string, source flagSource, ok bool) {
+	if _, ok := flag.(timeoutFlag); ok {
+		return "", flagSource{}, false
+	}
+
+	for _, env := range flagEnvVars(flag, envPrefix) {
+		if value, ok = os.LookupEnv(env); ok {
+			return value, flagSource{kind: flagSourceEnv, name: env}, true
+		}
+	}
+
+	if value, file, ok := section.lookup(flag); ok {
+		return value, flagSource{kind: flagSourceConfig, name: file}, true
+	}
+
+	return "", flagSource{}, false
+}
+
+func (a *App) setConfigCommand() {
+	if len(a.ConfigFiles) == 0 {
+		return
+	}
+
+	path := &Argument{
+		Name:     "command",
+		Usage:    L(i18n_app_config_show_command_usage),
+		Optional: true,
+		Variadic: true,
+	}
+
+	a.addBuiltinCommands(&Command{
+		Name:  configCommandName,
+		Usage: L(i18n_app_config_usage),
+		Subcommands: Commands{{
+			Name:      configShowCommandName,
+			Usage:     L(i18n_app_config_show_usage),
+			Arguments: []*Argument{path},
+			Action: func(ctx *Context) error {
+				return a.showConfig(ctx.Stdout(), MustGetArg[[]string](ctx, path.Name))
+			},
+		}},
+	})
+}
+
+// resolveCommandPath returns the commands along the path, whose words can be aliases or prefixes of the names,
+// as the parser matches them.
+func (a *App) resolveCommandPath(path []string) ([]*Command, error) {
+	flow := make([]*Command, 0, len(path))
+	commands := a.Commands
+
+	for i, word := range path {
+		j, err := matchCommand(createCommandSchema(commands), word, a.parseOptions())
+		if err != nil {
+			return nil, err
+		}
+
+		if j == -1 {
+			if i == 0 {
+				return nil, newErrorUnknownCommand(word)
+			}
+
+			return nil, newErrorSubcommandUnknown(commandPath(path[:i+1]))
+		}
+
+		flow = append(flow, commands[j])
+		commands = commands[j].Subcommands
+	}
+
+	return flow, nil
+}
+
+// showConfig prints the values the flags take when they are not given on the command line,
+// and where each one comes from. If path is set, only the flags the command with this path can read are printed.
+func (a *App) showConfig(w io.Writer, path []string) error {
+	type block struct {
+		title string
+		path  []string
+		flags Flags
+	}
+
+	blocks := []block{{title: a.Name, flags: a.GlobalFlags}}
+
+	if len(path) > 0 {
+		flow, err := a.resolveCommandPath(path)
+		if err != nil {
+			return err
+		}
+
+		path = make([]string, len(flow))
+		for i, command := range flow {
+			path[i] = command.Name
+		}
+
+		for i, command := range flow {
+			flags := command.PersistentFlags
+			if i == len(flow)-1 {
+				flags = append(slices.Clone(command.Flags), flags...)
+			}
+
+			blocks = append(blocks, block{title: strings.Join(path[:i+1], " "), path: path[:i+1], flags: flags})
+		}
+	} else {
+		var walk func(commands Commands, parent []string)
+		walk = func(commands Commands, parent []string) {
+			for _, command := range commands {
+				tree := append(slices.Clone(parent), command.Name)
+				flags := append(slices.Clone(command.Flags), command.PersistentFlags...)
+				blocks = append(blocks, block{title: strings.Join(tree, " "), path: tree, flags: flags})
+				walk(command.Subcommands, tree)
+			}
+		}
+		walk(a.Commands, nil)
+	}
+
+	for _, b := range blocks {
+		flags := slices.DeleteFunc(slices.Clone(b.flags), func(flag FlagDefinition) bool {
+			_, timeout := flag.(timeoutFlag)
+
+			return timeout || flag.GetName() == "help"
+		})
+		if len(flags) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s:\n", b.title); err != nil {
+			return err
+		}
+
+		section := a.config.section(b.path)
+
+		for _, flag := range flags {
+			value, source, ok := lookupFlagSource(flag, a.EnvPrefix, section)
+			if !ok && flagDefault(flag) != "" {
+				value, source = flagDefault(flag), flagSource{kind: flagSourceDefault}
+			}
+
+			if _, err := fmt.Fprintf(w, "  --%s = %s (%s)\n", flag.GetName(), value, source); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	files := []string{
+		writeConfigFile(t, "base.toml", "verbose = true\n[deploy.status]\ntimeout = \"10s\"\nregions = [\"eu\", \"us\"]\n"),
+		writeConfigFile(t, "override.json", `{"deploy": {"status": {"timeout": "20s", "limit": 1000000}}}`),
+		writeConfigFile(t, "extra.yaml", "deploy:\n  env: prod\n"),
+		filepath.Join(t.TempDir(), "missing.toml"),
+	}
+
+	layers, err := loadConfigFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path []string
+		flag string
+		want string
+		file string
+	}{
+		{nil, "verbose", "true", files[0]},
+		{[]string{"deploy", "status"}, "timeout", "20s", files[1]},
+		{[]string{"deploy", "status"}, "regions", "eu,us", files[0]},
+		{[]string{"deploy", "status"}, "limit", "1000000", files[1]},
+		{[]string{"deploy"}, "env", "prod", files[2]},
+	}
+
+	for _, tt := range tests {
+		value, file, ok := layers.section(tt.path).lookup(&FlagValue[string]{Name: tt.flag})
+		if !ok || value != tt.want || file != tt.file {
+			t.Errorf("%v %s: got %q from %s, want %q from %s", tt.path, tt.flag, value, file, tt.want, tt.file)
+		}
+	}
+
+	if _, _, ok := layers.section(nil).lookup(&FlagValue[string]{Name: "deploy"}); ok {
+		t.Error("a command table must not be read as a flag")
+	}
+
+	_, err = loadConfigFiles([]string{writeConfigFile(t, "broken.toml", "timeout = ")})
+	if !errors.Is(err, ErrorConfigFile) {
+		t.Errorf("got %v, want a config file error", err)
+	}
+
+	_, err = loadConfigFiles([]string{writeConfigFile(t, "config.ini", "a=b")})
+	if !errors.Is(err, ErrorConfigFile) {
+		t.Errorf("got %v, want a config file error for an unsupported format", err)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := &FlagValue[time.Duration]{Name: "timeout", Default: time.Second}
+	retries := &FlagValue[int]{Name: "retries", Default: 1}
+	env := &FlagValue[string]{Name: "env", Default: "dev"}
+	verbose := &FlagValue[bool]{Name: "verbose"}
+
+	app := &App{
+		Name:        "test",
+		EnvPrefix:   "TEST",
+		ConfigFiles: []string{writeConfigFile(t, "app.toml", "verbose = true\n[deploy]\ntimeout = \"30s\"\nretries = 5\n")},
+		GlobalFlags: Flags{verbose},
+		Commands:    Commands{{Name: "deploy", Aliases: []string{"dp"}, Flags: Flags{timeout, retries, env}}},
+	}
+	app.setConfigCommand()
+
+	var err error
+
+	app.config, err = loadConfigFiles(app.ConfigFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_RETRIES", "3")
+
+	ctx := &Context{}
+	if err = runCommand(app, ctx, "deploy --retries=7"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := MustFlag[time.Duration](ctx, "timeout"); v != 30*time.Second {
+		t.Errorf("got timeout %v, want 30s from the config", v)
+	}
+
+	if v := MustFlag[int](ctx, "retries"); v != 7 {
+		t.Errorf("got retries %v, want 7 from the command line", v)
+	}
+
+	if v := MustFlag[string](ctx, "env"); v != "dev" {
+		t.Errorf("got env %v, want the default", v)
+	}
+
+	if v := MustFlag[bool](ctx, "verbose"); v != true {
+		t.Errorf("got verbose %v, want true from the config", v)
+	}
+
+	if err = runCommand(app, ctx, "deploy"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := MustFlag[int](ctx, "retries"); v != 3 {
+		t.Errorf("got retries %v, want 3 from the environment", v)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = app.showConfig(buf, []string{"deploy"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"--verbose = true (" + flagSource{kind: flagSourceConfig, name: app.ConfigFiles[0]}.String() + ")",
+		"--timeout = 30s (" + flagSource{kind: flagSourceConfig, name: app.ConfigFiles[0]}.String() + ")",
+		"--retries = 3 (" + flagSource{kind: flagSourceEnv, name: "TEST_RETRIES"}.String() + ")",
+		"--env = dev (" + flagSource{kind: flagSourceDefault}.String() + ")",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("config show does not contain %q:\n%s", line, buf.String())
+		}
+	}
+
+	aliased := &bytes.Buffer{}
+	if err = app.showConfig(aliased, []string{"dp"}); err != nil || aliased.String() != buf.String() {
+		t.Errorf("config show dp printed %q, %v, want the same as config show deploy", aliased.String(), err)
+	}
+}
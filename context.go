@@ -1,24 +1,37 @@
 package replyme
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"github.com/google/uuid"
 	"io"
+	"net"
+	"net/url"
 	"os/exec"
+	"strings"
 	"time"
 )
 
+// execWaitDelay is how long a killed subprocess can keep its output open, see exec.Cmd.WaitDelay.
+const execWaitDelay = time.Second
+
 type ctxInterface interface { //nolint:interfacebloat
 	GetName() string
 	GetCommandNameTree() []string
+	Args() []string
 	GetFlagInt(name string, defaultValue int) int
 	GetFlagString(name string, defaultValue string) string
 	GetFlagIntArray(name string) []int
 	GetFlagStringArray(name string) []string
 	GetFlagBool(name string) bool
+	GetFlagFloat(name string, defaultValue float64) float64
+	GetFlagFloatArray(name string) []float64
+	GetFlagDuration(name string, defaultValue time.Duration) time.Duration
+	GetFlagTime(name string, defaultValue time.Time) time.Time
+	GetFlagURL(name string) *url.URL
+	GetFlagIP(name string) net.IP
+	GetFlagCounter(name string) int
 	Print(data ...interface{})
 	Printf(format string, data ...interface{})
 	PrintMarkdown(markdown string, data ...interface{})
@@ -29,6 +42,7 @@ type ctxInterface interface { //nolint:interfacebloat
 	StartTime() time.Time
 	Elapsed() time.Duration
 	Command() string
+	Stdin() io.Reader
 	Stdout() io.Writer
 	Stderr() io.Writer
 	Ctx() context.Context
@@ -42,7 +56,6 @@ type ctxInterface interface { //nolint:interfacebloat
 	Exec(cmd string, args ...string) (string, string, error)
 	ExecLive(cmd string, args ...string) error
 	ExecSilent(cmd string, args ...string) error
-	streamOutput(r io.Reader, status logMsgStatus)
 	SelectOne(p *TUISelectOneParams) (TUISelectOneResult, error)
 	InputText(p *TUIInputTextParams) (string, error)
 	InputInt(p *TUIInputIntParams) (int, error)
@@ -71,14 +84,18 @@ type logMsg struct {
 }
 
 func createPreContext(command *Command, ast *ASTNode) *Context {
-	ctx, cancel := context.WithCancel(context.Background())
+	return createContext(context.Background(), command, ast)
+}
+
+// createContext creates the Context of the command, which is cancelled with parent.
+func createContext(parent context.Context, command *Command, ast *ASTNode) *Context {
+	ctx, cancel := context.WithCancel(parent)
 
 	return &Context{
 		ctx:       ctx,
 		cancel:    cancel,
 		command:   command,
 		ast:       ast,
-		memory:    &memory,
 		startTime: time.Now(),
 	}
 }
@@ -88,15 +105,19 @@ type Context struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	command    *Command
+	flags      Flags
 	ast        *ASTNode
-	memory     *map[string]interface{}
+	session    *session
 	emitLog    func(logMsg)
 	emitTUI    func(TUIRequest)
 	emitTUICLI func(TUIRequest, chan<- bool)
+	stdin      io.Reader
 	stdout     io.Writer
 	stderr     io.Writer
 	startTime  time.Time
 	isCLI      bool
+	// The settings of the running command line, used by `source` to run a script in the same session
+	params fullRunCommandParams
 }
 
 // GetName - returns the name of the command.
@@ -109,29 +130,79 @@ func (c *Context) GetCommandNameTree() []string {
 	return c.ast.CommandTree
 }
 
+// Args returns the positional arguments of the command line, including the ones passed through
+// to commands with PassThroughArgs.
+func (c *Context) Args() []string {
+	return c.ast.Args
+}
+
+// getFlags returns the flags the command can read, including the inherited persistent and global flags.
+func (c *Context) getFlags() Flags {
+	if c.flags == nil {
+		return c.command.Flags
+	}
+
+	return c.flags
+}
+
 // GetFlagInt is a method for getting a flag int value.
 func (c *Context) GetFlagInt(name string, defaultValue int) int {
-	return c.command.Flags.GetFlagInt(name, defaultValue)
+	return c.getFlags().GetFlagInt(name, defaultValue)
 }
 
 // GetFlagString is a method for getting a flag string value.
 func (c *Context) GetFlagString(name string, defaultValue string) string {
-	return c.command.Flags.GetFlagString(name, defaultValue)
+	return c.getFlags().GetFlagString(name, defaultValue)
 }
 
 // GetFlagIntArray is a method for getting a flag int array value.
 func (c *Context) GetFlagIntArray(name string) []int {
-	return c.command.Flags.GetFlagIntArray(name)
+	return c.getFlags().GetFlagIntArray(name)
 }
 
 // GetFlagStringArray is a method for getting a flag string array value.
 func (c *Context) GetFlagStringArray(name string) []string {
-	return c.command.Flags.GetFlagStringArray(name)
+	return c.getFlags().GetFlagStringArray(name)
 }
 
 // GetFlagBool is a method for getting a flag bool value.
 func (c *Context) GetFlagBool(name string) bool {
-	return c.command.Flags.GetFlagBool(name)
+	return c.getFlags().GetFlagBool(name)
+}
+
+// GetFlagFloat is a method for getting a flag float64 value.
+func (c *Context) GetFlagFloat(name string, defaultValue float64) float64 {
+	return c.getFlags().GetFlagFloat(name, defaultValue)
+}
+
+// GetFlagFloatArray is a method for getting a flag float64 array value.
+func (c *Context) GetFlagFloatArray(name string) []float64 {
+	return c.getFlags().GetFlagFloatArray(name)
+}
+
+// GetFlagDuration is a method for getting a flag time.Duration value.
+func (c *Context) GetFlagDuration(name string, defaultValue time.Duration) time.Duration {
+	return c.getFlags().GetFlagDuration(name, defaultValue)
+}
+
+// GetFlagTime is a method for getting a flag time.Time value.
+func (c *Context) GetFlagTime(name string, defaultValue time.Time) time.Time {
+	return c.getFlags().GetFlagTime(name, defaultValue)
+}
+
+// GetFlagURL is a method for getting a flag *url.URL value.
+func (c *Context) GetFlagURL(name string) *url.URL {
+	return c.getFlags().GetFlagURL(name)
+}
+
+// GetFlagIP is a method for getting a flag net.IP value.
+func (c *Context) GetFlagIP(name string) net.IP {
+	return c.getFlags().GetFlagIP(name)
+}
+
+// GetFlagCounter is a method for getting the number of times a counter flag was given.
+func (c *Context) GetFlagCounter(name string) int {
+	return c.getFlags().GetFlagCounter(name)
 }
 
 // Print is a method for printing a message.
@@ -216,6 +287,17 @@ func (c *Context) Command() string {
 	return c.ast.FullCommand
 }
 
+// Stdin is a method for getting the input of the command: the output of the previous command of a pipeline,
+// a file redirected with `<`, or the standard input in the CLI. Otherwise, it is empty.
+// Commands that read it must set ReadsStdin.
+func (c *Context) Stdin() io.Reader {
+	if c.stdin == nil {
+		return strings.NewReader("")
+	}
+
+	return c.stdin
+}
+
 // Stdout is a method for getting the stdout writer.
 func (c *Context) Stdout() io.Writer {
 	return c.stdout
@@ -246,40 +328,37 @@ func (c *Context) IsCancelled() bool {
 	}
 }
 
-// Set is a method for setting a value in the memory.
-func (c *Context) Set(key string, value interface{}) {
-	if c.memory == nil {
-		newMem := make(map[string]interface{})
-		c.memory = &newMem
+// memory returns the session of the application the command runs in.
+func (c *Context) memory() *session {
+	if c.session == nil {
+		c.session = &session{}
 	}
 
-	(*c.memory)[key] = value
+	return c.session
+}
+
+// Set is a method for setting a value in the memory. The memory is shared by the whole session of the application,
+// so the value can be referenced on later command lines as `$key`, e.g. the ID of a created resource.
+func (c *Context) Set(key string, value interface{}) {
+	c.memory().set(key, value)
 }
 
 // Delete is a method for deleting a value from the memory.
 func (c *Context) Delete(key string) {
-	if c.memory != nil {
-		delete(*c.memory, key)
-	}
+	c.memory().delete(key)
 }
 
 // Get is a method for getting a value from the memory.
 func (c *Context) Get(key string) interface{} {
-	if c.memory != nil {
-		return (*c.memory)[key]
-	}
+	value, _ := c.memory().get(key)
 
-	return nil
+	return value
 }
 
 // MustGetString is a method for getting a value from the memory and converting it to a string.
 func (c *Context) MustGetString(key string) string {
-	if c.memory != nil {
-		if value, ok := (*c.memory)[key]; ok {
-			if d, ok := value.(string); ok {
-				return d
-			}
-		}
+	if d, ok := c.Get(key).(string); ok {
+		return d
 	}
 
 	return ""
@@ -287,12 +366,8 @@ func (c *Context) MustGetString(key string) string {
 
 // MustGetInt is a method for getting a value from the memory and converting it to an int.
 func (c *Context) MustGetInt(key string) int {
-	if c.memory != nil {
-		if value, ok := (*c.memory)[key]; ok {
-			if d, ok := value.(int); ok {
-				return d
-			}
-		}
+	if d, ok := c.Get(key).(int); ok {
+		return d
 	}
 
 	return 0
@@ -300,7 +375,7 @@ func (c *Context) MustGetInt(key string) int {
 
 // Exec is a method for executing a shell command.
 func (c *Context) Exec(cmd string, args ...string) (string, string, error) {
-	command := exec.CommandContext(c.ctx, cmd, args...)
+	command := c.subprocess(cmd, args...)
 
 	var stdout bytes.Buffer
 
@@ -316,42 +391,45 @@ func (c *Context) Exec(cmd string, args ...string) (string, string, error) {
 
 // ExecLive is a method for executing a shell command in a live environment.
 func (c *Context) ExecLive(cmd string, args ...string) error {
-	command := exec.CommandContext(c.ctx, cmd, args...)
+	command := c.subprocess(cmd, args...)
 
-	stdoutPipe, err := command.StdoutPipe()
-	if err != nil {
-		return err
-	}
-
-	stderrPipe, err := command.StderrPipe()
-	if err != nil {
-		return err
-	}
+	stdout := &emitLogWriter{emitLog: c.emitLog, status: logMsgStatusPrint}
+	stderr := &emitLogWriter{emitLog: c.emitLog, status: logMsgStatusError}
+	command.Stdout = stdout
+	command.Stderr = stderr
 
-	if err := command.Start(); err != nil {
-		return err
-	}
+	err := command.Run()
 
-	go c.streamOutput(stdoutPipe, logMsgStatusPrint)
-	go c.streamOutput(stderrPipe, logMsgStatusError)
+	stdout.Flush()
+	stderr.Flush()
 
-	return command.Wait()
+	return err
 }
 
 // ExecSilent is a method for executing a shell command silently.
 func (c *Context) ExecSilent(cmd string, args ...string) error {
+	return c.subprocess(cmd, args...).Run()
+}
+
+// subprocess creates a subprocess that is killed when the command is cancelled or its timeout is hit.
+func (c *Context) subprocess(cmd string, args ...string) *exec.Cmd {
 	command := exec.CommandContext(c.ctx, cmd, args...)
+	// The output of the processes started by the killed one is not waited for longer than this
+	command.WaitDelay = execWaitDelay
 
-	return command.Run()
+	return command
 }
 
-// SelectOne is a method that triggers TUI to receive one item from the list from the user.
-func (c *Context) SelectOne(p *TUISelectOneParams) (TUISelectOneResult, error) {
+// prompt opens the prompt in the TUI and waits for the answer. If the command is cancelled or times out first,
+// it returns the error of its context, and the TUI closes the prompt.
+func (c *Context) prompt(t tuiType, payload interface{}) TUIResponse {
 	req := TUIRequest{
-		ID:       uuid.NewString(),
-		Type:     tuiTypeSelectOne,
-		Payload:  *p,
-		Response: make(chan TUIResponse),
+		ID:      uuid.NewString(),
+		Type:    t,
+		Payload: payload,
+		// The TUI does not block when it answers a prompt that is no longer waited for
+		Response: make(chan TUIResponse, 1),
+		ctx:      c.ctx,
 	}
 
 	if c.isCLI {
@@ -361,13 +439,26 @@ func (c *Context) SelectOne(p *TUISelectOneParams) (TUISelectOneResult, error) {
 
 		defer func() {
 			// Wait for the CLI TUI to finish
-			<-close
+			select {
+			case <-close:
+			case <-c.Done():
+			}
 		}()
 	} else {
 		go c.emitTUI(req)
 	}
 
-	res := <-req.Response
+	select {
+	case res := <-req.Response:
+		return res
+	case <-c.Done():
+		return TUIResponse{Err: c.ctx.Err()}
+	}
+}
+
+// SelectOne is a method that triggers TUI to receive one item from the list from the user.
+func (c *Context) SelectOne(p *TUISelectOneParams) (TUISelectOneResult, error) {
+	res := c.prompt(tuiTypeSelectOne, *p)
 	if res.Err != nil {
 		return TUISelectOneResult{}, res.Err
 	}
@@ -377,27 +468,7 @@ func (c *Context) SelectOne(p *TUISelectOneParams) (TUISelectOneResult, error) {
 
 // InputText is a method that triggers TUI to receive text from the user.
 func (c *Context) InputText(p *TUIInputTextParams) (string, error) {
-	req := TUIRequest{
-		ID:       uuid.NewString(),
-		Type:     tuiTypeInputText,
-		Payload:  *p,
-		Response: make(chan TUIResponse),
-	}
-
-	if c.isCLI {
-		close := make(chan bool)
-
-		go c.emitTUICLI(req, close)
-
-		defer func() {
-			// Wait for the CLI TUI to finish
-			<-close
-		}()
-	} else {
-		go c.emitTUI(req)
-	}
-
-	res := <-req.Response
+	res := c.prompt(tuiTypeInputText, *p)
 	if res.Err != nil {
 		return "", res.Err
 	}
@@ -407,27 +478,7 @@ func (c *Context) InputText(p *TUIInputTextParams) (string, error) {
 
 // InputInt is a method that triggers TUI to receive integer from the user.
 func (c *Context) InputInt(p *TUIInputIntParams) (int, error) {
-	req := TUIRequest{
-		ID:       uuid.NewString(),
-		Type:     tuiTypeInputInt,
-		Payload:  *p,
-		Response: make(chan TUIResponse),
-	}
-
-	if c.isCLI {
-		close := make(chan bool)
-
-		go c.emitTUICLI(req, close)
-
-		defer func() {
-			// Wait for the CLI TUI to finish
-			<-close
-		}()
-	} else {
-		go c.emitTUI(req)
-	}
-
-	res := <-req.Response
+	res := c.prompt(tuiTypeInputInt, *p)
 	if res.Err != nil {
 		return 0, res.Err
 	}
@@ -437,27 +488,7 @@ func (c *Context) InputInt(p *TUIInputIntParams) (int, error) {
 
 // InputFile is a method that triggers TUI to receive file from the user.
 func (c *Context) InputFile(p *TUIInputFileParams) (TUIInputFileResult, error) {
-	req := TUIRequest{
-		ID:       uuid.NewString(),
-		Type:     tuiTypeInputFile,
-		Payload:  *p,
-		Response: make(chan TUIResponse),
-	}
-
-	if c.isCLI {
-		close := make(chan bool)
-
-		go c.emitTUICLI(req, close)
-
-		defer func() {
-			// Wait for the CLI TUI to finish
-			<-close
-		}()
-	} else {
-		go c.emitTUI(req)
-	}
-
-	res := <-req.Response
+	res := c.prompt(tuiTypeInputFile, *p)
 	if res.Err != nil {
 		return TUIInputFileResult{}, res.Err
 	}
@@ -467,43 +498,51 @@ func (c *Context) InputFile(p *TUIInputFileParams) (TUIInputFileResult, error) {
 
 // Confirm is a method that triggers TUI to receive confirmation from the user.
 func (c *Context) Confirm(p *TUIConfirmParams) (bool, error) {
-	req := TUIRequest{
-		ID:       uuid.NewString(),
-		Type:     tuiTypeConfirm,
-		Payload:  *p,
-		Response: make(chan TUIResponse),
+	res := c.prompt(tuiTypeConfirm, *p)
+	if res.Err != nil {
+		return false, res.Err
 	}
 
-	if c.isCLI {
-		close := make(chan bool)
+	return res.Value.(bool), nil
+}
 
-		go c.emitTUICLI(req, close)
+// emitLogWriter emits the output of a subprocess as logs, a log per line.
+type emitLogWriter struct {
+	emitLog func(logMsg)
+	status  logMsgStatus
+	buf     []byte
+}
 
-		defer func() {
-			// Wait for the CLI TUI to finish
-			<-close
-		}()
-	} else {
-		go c.emitTUI(req)
-	}
+func (w *emitLogWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
 
-	res := <-req.Response
-	if res.Err != nil {
-		return false, res.Err
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		w.emit(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
 	}
 
-	return res.Value.(bool), nil
+	return len(p), nil
 }
 
-func (c *Context) streamOutput(r io.Reader, status logMsgStatus) {
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		if c.emitLog != nil {
-			c.emitLog(logMsg{
-				Status:  status,
-				Content: scanner.Text(),
-				Time:    time.Now(),
-			})
-		}
+// Flush emits the last line if it does not end with a newline.
+func (w *emitLogWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *emitLogWriter) emit(line string) {
+	if w.emitLog != nil {
+		w.emitLog(logMsg{
+			Status:  w.status,
+			Content: strings.TrimSuffix(line, "\r"),
+			Time:    time.Now(),
+		})
 	}
 }
@@ -131,13 +131,12 @@ func create() *Context {
 		cancel:    cancel,
 		command:   command,
 		ast:       ast,
-		memory:    new(map[string]interface{}),
+		session:   &session{},
 		emitLog:   func(msg logMsg) {},
 		stdout:    bytes.NewBuffer(nil),
 		stderr:    bytes.NewBuffer(nil),
 		startTime: time.Now(),
 	}
-	context.memory = &map[string]interface{}{}
 
 	return context
 }
@@ -359,7 +358,7 @@ func TestContext_Delete(t *testing.T) {
 	context.Delete("test2")
 	context.Delete("test3")
 
-	for k, v := range *context.memory {
+	for k, v := range context.session.memory {
 		t.Fatalf("Memory has data after delete: %v (%v)", k, v)
 	}
 }
@@ -3,6 +3,8 @@ package replyme
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 var ErrorUnknownCommand = errors.New("unknown command")
@@ -11,12 +13,50 @@ func newErrorUnknownCommand(cmd string) error {
 	return fmt.Errorf("%w: %s", ErrorUnknownCommand, cmd)
 }
 
+// UnknownCommandError is returned when the command line starts with a word that is not a command.
+type UnknownCommandError struct {
+	Name string
+	// Commands with a similar name, the closest first
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrorUnknownCommand, e.Name)
+}
+
+func (e *UnknownCommandError) Unwrap() error {
+	return ErrorUnknownCommand
+}
+
+// GetSuggestions returns the commands with a similar name.
+func (e *UnknownCommandError) GetSuggestions() []string {
+	return e.Suggestions
+}
+
 var ErrorArgumentNotFound = errors.New("argument not found for command")
 
 func newErrorArgumentNotFound(cmd string) error {
 	return fmt.Errorf("%w: %s", ErrorArgumentNotFound, cmd)
 }
 
+var ErrorInvalidArgument = errors.New("invalid argument")
+
+func newErrorInvalidArgument(arg string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrorInvalidArgument, arg, err)
+}
+
+var ErrorInvalidArguments = errors.New("invalid arguments declaration")
+
+func newErrorInvalidArguments(cmd, reason string) error {
+	return fmt.Errorf("%w: %s: %s", ErrorInvalidArguments, cmd, reason)
+}
+
+var ErrorInvalidFlagGroup = errors.New("invalid flag group declaration")
+
+func newErrorInvalidFlagGroup(cmd, reason string) error {
+	return fmt.Errorf("%w: %s: %s", ErrorInvalidFlagGroup, cmd, reason)
+}
+
 var ErrorCommandPanic = errors.New("cmdpanic")
 
 func newErrorCommandPanic(cmd string) error {
@@ -29,6 +69,18 @@ func newErrorUnknownFlagType(t string) error {
 	return fmt.Errorf("%w: %s", ErrorUnknownFlagType, t)
 }
 
+var ErrorInvalidFlagValue = errors.New("invalid flag value")
+
+func newErrorInvalidFlagValue(value, t string) error {
+	return fmt.Errorf("%w: %q is not a valid %s", ErrorInvalidFlagValue, value, t)
+}
+
+var ErrorInvalidFlagChoice = errors.New("invalid flag choice")
+
+func newErrorInvalidFlagChoice(flag, value string, choices []string) error {
+	return fmt.Errorf("%w: --%s=%s (%s)", ErrorInvalidFlagChoice, flag, value, strings.Join(choices, ", "))
+}
+
 var ErrorCommandEmpty = errors.New("command empty")
 
 var ErrorSubcommandUnknown = errors.New("unknown subcommand")
@@ -37,6 +89,289 @@ func newErrorSubcommandUnknown(cmd string) error {
 	return fmt.Errorf("%w: %s", ErrorSubcommandUnknown, cmd)
 }
 
+var ErrorAmbiguousCommand = errors.New("ambiguous command")
+
+func newErrorAmbiguousCommand(path, name string) error {
+	return fmt.Errorf("%w: %q is declared more than once in %s", ErrorAmbiguousCommand, name, path)
+}
+
+func newErrorAmbiguousPrefix(prefix string, matches []string) error {
+	return fmt.Errorf("%w: %q matches %s", ErrorAmbiguousCommand, prefix, strings.Join(matches, ", "))
+}
+
+// UnknownSubcommandError is returned when a word after a command that has subcommands is not one of them.
+type UnknownSubcommandError struct {
+	// Path of the parent command, e.g. `users/list`
+	Command string
+	Name    string
+	// Subcommands with a similar name, the closest first
+	Suggestions []string
+}
+
+func (e *UnknownSubcommandError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrorSubcommandUnknown, e.Name)
+}
+
+func (e *UnknownSubcommandError) Unwrap() error {
+	return ErrorSubcommandUnknown
+}
+
+// GetSuggestions returns the subcommands with a similar name.
+func (e *UnknownSubcommandError) GetSuggestions() []string {
+	return e.Suggestions
+}
+
+var ErrorUnknownFlag = errors.New("unknown flag")
+
+// UnknownFlagError is returned when a flag that the command does not declare is given.
+type UnknownFlagError struct {
+	// Path of the command, e.g. `users/list`
+	Command string
+	Flag    string
+	// Flags with a similar name, the closest first
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrorUnknownFlag, flagDisplayName(e.Flag), e.Command)
+}
+
+func (e *UnknownFlagError) Unwrap() error {
+	return ErrorUnknownFlag
+}
+
+// GetSuggestions returns the flags with a similar name.
+func (e *UnknownFlagError) GetSuggestions() []string {
+	return e.Suggestions
+}
+
+var ErrorTooManyArguments = errors.New("too many arguments")
+
+// TooManyArgumentsError is returned when more positional arguments are given than the command declares.
+type TooManyArgumentsError struct {
+	// Path of the command, e.g. `users/list`
+	Command string
+	// The number of arguments the command declares
+	Max int
+	// The surplus arguments
+	Args []string
+}
+
+func (e *TooManyArgumentsError) Error() string {
+	return fmt.Sprintf("%s: %s takes %d, surplus: %s", ErrorTooManyArguments, e.Command, e.Max, strings.Join(e.Args, " "))
+}
+
+func (e *TooManyArgumentsError) Unwrap() error {
+	return ErrorTooManyArguments
+}
+
+var (
+	ErrorMutuallyExclusiveFlags = errors.New("mutually exclusive flags")
+	ErrorRequiredTogetherFlags  = errors.New("flags required together")
+	ErrorOneRequiredFlag        = errors.New("one of the flags is required")
+)
+
+// FlagGroupError is returned when the flags given to a command break one of its flag groups.
+// It wraps ErrorMutuallyExclusiveFlags, ErrorRequiredTogetherFlags or ErrorOneRequiredFlag.
+type FlagGroupError struct {
+	Command    string
+	Constraint FlagConstraint
+	// The flags of the group
+	Flags []string
+	// The flags of the group that are set
+	Set []string
+}
+
+func (e *FlagGroupError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Constraint.describe(e.Flags), e.Command)
+}
+
+func (e *FlagGroupError) Unwrap() error {
+	switch e.Constraint {
+	case FlagConstraintRequiredTogether:
+		return ErrorRequiredTogetherFlags
+	case FlagConstraintOneRequired:
+		return ErrorOneRequiredFlag
+	default:
+		return ErrorMutuallyExclusiveFlags
+	}
+}
+
+var ErrorInvalidStructTag = errors.New("invalid struct tag")
+
+func newErrorInvalidStructTag(field, reason string) error {
+	return fmt.Errorf("%w: %s: %s", ErrorInvalidStructTag, field, reason)
+}
+
+var ErrorConfigFile = errors.New("invalid config file")
+
+func newErrorConfigFile(file string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrorConfigFile, file, err)
+}
+
 var ErrorCommandUnclosedQuotes = errors.New("unclosed quotes")
 
+var ErrorChainSyntax = errors.New("syntax error")
+
+func newErrorSyntax(token string) error {
+	return fmt.Errorf("%w: unexpected %s", ErrorChainSyntax, token)
+}
+
+var ErrorStdinNotRead = errors.New("command does not read stdin")
+
+func newErrorStdinNotRead(cmd string) error {
+	return fmt.Errorf("%w: %s", ErrorStdinNotRead, cmd)
+}
+
+var ErrorNoAnswer = errors.New("no answer for the prompt")
+
+func newErrorNoAnswer(prompt string) error {
+	return fmt.Errorf("%w: %s", ErrorNoAnswer, prompt)
+}
+
+var ErrorInvalidAnswer = errors.New("invalid answer")
+
+func newErrorInvalidAnswer(prompt, answer string) error {
+	return fmt.Errorf("%w: %s: %q", ErrorInvalidAnswer, prompt, answer)
+}
+
+// ScriptError is returned when a line of a script fails.
+type ScriptError struct {
+	// Name of the script, see ScriptParams.Name
+	Script string
+	// Number of the line the statement starts at
+	Line int
+	// The statement that failed, empty for a syntax error
+	Command string
+	Err     error
+}
+
+func (e *ScriptError) Error() string {
+	if e.Command == "" {
+		return fmt.Sprintf("%s:%d: %s", e.Script, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %s: %s", e.Script, e.Line, e.Command, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+var ErrorCallDepth = errors.New("functions are nested too deeply")
+
+func newErrorCallDepth(name string) error {
+	return fmt.Errorf("%w: %s", ErrorCallDepth, name)
+}
+
+var ErrorSourceDepth = errors.New("scripts are nested too deeply")
+
+func newErrorSourceDepth(name string) error {
+	return fmt.Errorf("%w: %s", ErrorSourceDepth, name)
+}
+
+var ErrorUnknownAlias = errors.New("unknown alias")
+
+func newErrorUnknownAlias(name string) error {
+	return fmt.Errorf("%w: %s", ErrorUnknownAlias, name)
+}
+
+var ErrorPredefinedAlias = errors.New("predefined alias cannot be removed")
+
+func newErrorPredefinedAlias(name string) error {
+	return fmt.Errorf("%w: %s", ErrorPredefinedAlias, name)
+}
+
+var ErrorInvalidAlias = errors.New("invalid alias name")
+
+func newErrorInvalidAlias(name string) error {
+	return fmt.Errorf("%w: %q", ErrorInvalidAlias, name)
+}
+
+var ErrorCommandTimeout = errors.New("command timed out")
+
+// TimeoutError is returned when a command runs longer than its timeout, see Command.Timeout.
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+	// How long the command ran before it was stopped
+	Elapsed time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s after %s: %s", ErrorCommandTimeout, e.Timeout, e.Command)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return ErrorCommandTimeout
+}
+
+var ErrorRunAbandoned = errors.New("the command timed out and its output is closed")
+
+var ErrorUnknownJob = errors.New("no such job")
+
+func newErrorUnknownJob(spec string) error {
+	return fmt.Errorf("%w: %s", ErrorUnknownJob, spec)
+}
+
+var ErrorBackgroundJobs = errors.New("background jobs are only supported in the REPL")
+
+var ErrorUnclosedSubstitution = errors.New("unclosed substitution")
+
+var ErrorInvalidVariable = errors.New("invalid variable name")
+
+func newErrorInvalidVariable(name string) error {
+	return fmt.Errorf("%w: %q", ErrorInvalidVariable, name)
+}
+
+var ErrorValueInUse = errors.New("flag or argument is used by another running command")
+
+func newErrorValueInUse(name string) error {
+	return fmt.Errorf("%w: %s", ErrorValueInUse, name)
+}
+
+var ErrorInvalidAssignment = errors.New("expected NAME=VALUE")
+
+func newErrorInvalidAssignment(assignment string) error {
+	return fmt.Errorf("%w: %q", ErrorInvalidAssignment, assignment)
+}
+
 var ErrorIncompleteEscapeSequence = errors.New("incomplete escape sequence")
+
+var ErrorUnknownShell = errors.New("unknown shell")
+
+func newErrorUnknownShell(shell string) error {
+	return fmt.Errorf("%w: %s", ErrorUnknownShell, shell)
+}
+
+var ErrorRequiredFlag = errors.New("required flag is not set")
+
+// RequiredFlagError is returned when a required flag is set neither on the command line nor in the environment.
+type RequiredFlagError struct {
+	Command string
+	Flag    string
+}
+
+func (e *RequiredFlagError) Error() string {
+	return fmt.Sprintf("%s: --%s (%s)", ErrorRequiredFlag, e.Flag, e.Command)
+}
+
+func (e *RequiredFlagError) Unwrap() error {
+	return ErrorRequiredFlag
+}
+
+var ErrorRepeatedFlag = errors.New("flag is given more than once")
+
+// RepeatedFlagError is returned when a flag that takes a single value is given more than once.
+type RepeatedFlagError struct {
+	Command string
+	Flag    string
+}
+
+func (e *RepeatedFlagError) Error() string {
+	return fmt.Sprintf("%s: --%s (%s)", ErrorRepeatedFlag, e.Flag, e.Command)
+}
+
+func (e *RepeatedFlagError) Unwrap() error {
+	return ErrorRepeatedFlag
+}
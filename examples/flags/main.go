@@ -9,7 +9,7 @@ func main() {
 		Commands: []*replyme.Command{
 			{
 				Name: "yourName",
-				Flags: []replyme.Flag{
+				Flags: replyme.Flags{
 					&replyme.FlagValue[string]{
 						Name:  "name",
 						Usage: "Your name",
@@ -23,7 +23,7 @@ func main() {
 			},
 			{
 				Name: "register",
-				Flags: []replyme.Flag{
+				Flags: replyme.Flags{
 					&replyme.FlagValue[string]{
 						Name:  "login",
 						Usage: "Your login",
@@ -10,7 +10,7 @@ func main() {
 			{
 				Name:  "mainCommand",
 				Usage: "Main command",
-				Flags: []replyme.Flag{
+				Flags: replyme.Flags{
 					&replyme.FlagValue[string]{
 						Name:  "testFlag",
 						Usage: "Test flag",
@@ -50,7 +50,7 @@ func main() {
 
 							return nil
 						},
-						Flags: []replyme.Flag{
+						Flags: replyme.Flags{
 							&replyme.FlagValue[string]{
 								Name:  "testSubFlag",
 								Usage: "Test sub flag",
@@ -75,7 +75,7 @@ func main() {
 
 									return nil
 								},
-								Flags: []replyme.Flag{
+								Flags: replyme.Flags{
 									&replyme.FlagValue[string]{
 										Name:  "testSubSubFlag",
 										Usage: "Test sub/sub flag",
@@ -10,7 +10,7 @@ func main() {
 			{
 				Name:  "auth",
 				Usage: "Authenticates the user",
-				Flags: []replyme.Flag{
+				Flags: replyme.Flags{
 					&replyme.FlagValue[string]{
 						Name:  "server",
 						Usage: "The server to authenticate to",
@@ -20,7 +20,7 @@ func main() {
 					{
 						Name:  "login",
 						Usage: "Please login",
-						Flags: []replyme.Flag{
+						Flags: replyme.Flags{
 							&replyme.FlagValue[string]{
 								Name:  "username",
 								Usage: "The username to login with",
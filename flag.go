@@ -2,14 +2,20 @@ package replyme
 
 import (
 	"errors"
+	"fmt"
 	"golang.org/x/exp/slices"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Flag is an interface for getting information about flags and parsing it.
-type Flag interface {
+// FlagDefinition is an interface for getting information about flags and parsing it.
+// A flag can also implement FlagCompleter, FlagDefaulter, FlagValidator, FlagEnvReader and TypedFlag
+// to support completion, default values, validation, environment variables and the flag type registry.
+type FlagDefinition interface {
 	// GetName returns the name of the flag.
 	GetName() string
 	// GetAlias returns the alias of the flag.
@@ -28,6 +34,114 @@ type Flag interface {
 	Clear()
 }
 
+// FlagCompleter is a flag whose values are completed with Tab.
+type FlagCompleter interface {
+	// Completions returns the values offered when completing the flag.
+	Completions(ctx *Context, partial string) []string
+}
+
+// FlagDefaulter is a flag with a default value.
+type FlagDefaulter interface {
+	// GetDefault returns the default value of the flag formatted as a string, or "" if there is none.
+	GetDefault() string
+	// ApplyDefault sets the default value if the flag has no value yet.
+	ApplyDefault()
+	// IsSet returns whether the flag was given on the command line or in the environment, not by its default.
+	IsSet() bool
+}
+
+// FlagValidator is a flag that can be required or restricted to a set of values.
+type FlagValidator interface {
+	// IsRequired returns whether the flag must be set.
+	IsRequired() bool
+	// GetChoices returns the allowed values of the flag.
+	GetChoices() []string
+}
+
+// FlagEnvReader is a flag that is read from environment variables.
+type FlagEnvReader interface {
+	// GetEnvVars returns the environment variables the flag is read from.
+	GetEnvVars() []string
+}
+
+// TypedFlag is a flag that knows its type in the flag type registry.
+// The type of other flags is looked up by their ValueType.
+type TypedFlag interface {
+	// Type returns the type of the flag from the flag type registry.
+	Type() FlagType
+	// Kind returns how the flag is written on the command line.
+	Kind() FlagKind
+}
+
+func completeFlagValue(flag FlagDefinition, ctx *Context, partial string) []string {
+	if f, ok := flag.(FlagCompleter); ok {
+		return f.Completions(ctx, partial)
+	}
+
+	return nil
+}
+
+func flagDefault(flag FlagDefinition) string {
+	if f, ok := flag.(FlagDefaulter); ok {
+		return f.GetDefault()
+	}
+
+	return ""
+}
+
+func applyFlagDefault(flag FlagDefinition) {
+	if f, ok := flag.(FlagDefaulter); ok {
+		f.ApplyDefault()
+	}
+}
+
+// flagIsSet returns whether the flag was set. A flag without a default is set if it has a value.
+func flagIsSet(flag FlagDefinition) bool {
+	if f, ok := flag.(FlagDefaulter); ok {
+		return f.IsSet()
+	}
+
+	_, err := flag.ParsedValue()
+
+	return err == nil
+}
+
+func flagRequired(flag FlagDefinition) bool {
+	if f, ok := flag.(FlagValidator); ok {
+		return f.IsRequired()
+	}
+
+	return false
+}
+
+func flagChoices(flag FlagDefinition) []string {
+	if f, ok := flag.(FlagValidator); ok {
+		return f.GetChoices()
+	}
+
+	return nil
+}
+
+func typeOfFlag(flag FlagDefinition) FlagType {
+	if f, ok := flag.(TypedFlag); ok {
+		return f.Type()
+	}
+
+	if info := flagTypes.lookupName(flag.ValueType()); info != nil {
+		return info.flagType
+	}
+
+	return FlagTypeCustom
+}
+
+func kindOfFlag(flag FlagDefinition) FlagKind {
+	if f, ok := flag.(TypedFlag); ok {
+		return f.Kind()
+	}
+
+	return typeOfFlag(flag).Kind()
+}
+
 // FlagValue is a structure for passing information about flags to a command.
 type FlagValue[T any] struct {
 	// Flag name
@@ -37,10 +151,23 @@ type FlagValue[T any] struct {
 	// Flag alias
 	Alias string
 	// Flag parser
-	Parser         func(s string) (T, error)
+	Parser func(s string) (T, error)
+	// Returns the values offered when the flag value is completed with Tab
+	Complete func(ctx *Context, partial string) []string
+	// The value used when the flag is set neither on the command line nor in the environment
+	Default T
+	// The command fails if the flag is not set
+	Required bool
+	// Environment variables the flag is read from if it is not set on the command line
+	EnvVars []string
+	// Allowed values of the flag. If set, any other value is rejected
+	Choices []string
+	// Layout of time.Time flags, time.RFC3339 by default
+	Layout         string
 	preParsedValue string
 	value          T
 	hasValue       bool
+	isSet          bool
 }
 
 // GetUsage returns the usage of the flag.
@@ -50,6 +177,9 @@ func (f *FlagValue[T]) GetUsage() string {
 
 // ParsedValue returns the parsed value of the flag.
 func (f *FlagValue[T]) ParsedValue() (interface{}, error) {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
 	if !f.hasValue {
 		return nil, errors.New("value is nil")
 	}
@@ -69,195 +199,363 @@ func (f *FlagValue[T]) GetAlias() string {
 
 // ValueType returns the type of the value.
 func (f *FlagValue[T]) ValueType() string {
-	var zero T
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// Type returns the type of the flag from the flag type registry.
+// Unregistered types are FlagTypeCustom and can only be parsed by the Parser.
+func (f *FlagValue[T]) Type() FlagType {
+	if info := flagTypes.lookup(reflect.TypeOf((*T)(nil)).Elem()); info != nil {
+		return info.flagType
+	}
+
+	return FlagTypeCustom
+}
+
+// Kind returns how the flag is written on the command line.
+// Flags of unregistered slice types, parsed by their Parser, are FlagKindSlice.
+func (f *FlagValue[T]) Kind() FlagKind {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if info := flagTypes.lookup(t); info != nil {
+		return info.kind
+	}
+
+	if t.Kind() == reflect.Slice {
+		return FlagKindSlice
+	}
 
-	return reflect.TypeOf(zero).String()
+	return FlagKindValue
 }
 
 // Value returns the value of the flag.
 func (f *FlagValue[T]) Value() string {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
 	return f.preParsedValue
 }
 
-// Parse parses the flag.
+// Parse parses the flag. Parsing a list flag again appends to its values,
+// and parsing a counter flag with "true" (given without a value) increments it.
 func (f *FlagValue[T]) Parse(flag string) (interface{}, error) {
 	var parsed T
 
-	var err error
+	err := f.validateChoice(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := f.Kind()
+	if kind == FlagKindCounter && flag == "true" {
+		return f.increment()
+	}
 
 	if f.Parser != nil {
 		parsed, err = f.Parser(flag)
+		if err != nil {
+			return parsed, err
+		}
+	} else {
+		info := flagTypes.lookup(reflect.TypeOf((*T)(nil)).Elem())
+		if info == nil {
+			return nil, newErrorUnknownFlagType(f.ValueType())
+		}
 
-		return parsed, err
-	}
+		d, err := info.parse(flagParseOptions{Layout: f.Layout}, flag)
+		if err != nil {
+			return nil, err
+		}
 
-	switch any(parsed).(type) {
-	case int:
-		var d interface{}
-		d, err = f.parseInt(flag)
-		parsed = d.(T)
-	case string:
-		var d interface{}
-		d = f.parseString(flag)
 		parsed = d.(T)
-	case []int:
-		var d interface{}
-		d, err = f.parseIntArray(flag)
-		parsed = d.(T)
-	case []string:
-		var d interface{}
-		d = f.parseStringArray(flag)
-		parsed = d.(T)
-	case bool:
-		var d interface{}
-		d, err = f.parseBool(flag)
-		parsed = d.(T)
-	default:
-		return nil, newErrorUnknownFlagType(reflect.TypeOf(parsed).String())
 	}
 
-	f.value = parsed
-	f.hasValue = true
+	if kind == FlagKindSlice && f.hasValue {
+		parsed = reflect.AppendSlice(reflect.ValueOf(f.value), reflect.ValueOf(parsed)).Interface().(T)
+		flag = f.preParsedValue + "," + flag
+	}
+
+	f.setValue(flag, parsed)
 
-	return parsed, err
+	return parsed, nil
 }
 
-func (f *FlagValue[T]) parseInt(flag string) (v interface{}, err error) {
-	v, err = strconv.Atoi(flag)
-	if err != nil {
-		return 0, err
+// increment adds one to the value of a counter flag given without a value.
+func (f *FlagValue[T]) increment() (interface{}, error) {
+	v := reflect.ValueOf(&f.value).Elem()
+	if !v.CanInt() {
+		return nil, newErrorUnknownFlagType(f.ValueType())
 	}
 
-	return
+	if !f.hasValue {
+		v.SetInt(0)
+	}
+
+	v.SetInt(v.Int() + 1)
+	f.preParsedValue = strconv.FormatInt(v.Int(), 10)
+	f.hasValue = true
+	f.isSet = true
+
+	return f.value, nil
+}
+
+func (f *FlagValue[T]) setValue(flag string, value T) {
+	f.preParsedValue = flag
+	f.value = value
+	f.hasValue = true
+	f.isSet = true
 }
 
-func (f *FlagValue[T]) parseString(flag string) (v interface{}) {
-	return flag
+// IsSet returns whether the flag was given on the command line or in the environment, not by its default.
+func (f *FlagValue[T]) IsSet() bool {
+	mirrorMu.RLock()
+	defer mirrorMu.RUnlock()
+
+	return f.isSet
 }
 
-func (f *FlagValue[T]) parseIntArray(flag string) (interface{}, error) {
-	arr := []int{}
+// validateChoice checks that the value (or every element of a list) is one of the Choices.
+func (f *FlagValue[T]) validateChoice(flag string) error {
+	if len(f.Choices) == 0 {
+		return nil
+	}
 
-	parts := strings.Split(flag, ",")
+	values := []string{flag}
+	if f.Kind() == FlagKindSlice {
+		values = splitList(flag)
+	}
 
-	for _, part := range parts {
-		n, convErr := strconv.Atoi(strings.TrimSpace(part))
-		if convErr != nil {
-			return nil, convErr
+	for _, value := range values {
+		if !slices.Contains(f.Choices, value) {
+			return newErrorInvalidFlagChoice(f.Name, value, f.Choices)
 		}
+	}
 
-		arr = append(arr, n)
+	return nil
+}
+
+// Clear clears the flag.
+func (f *FlagValue[T]) Clear() {
+	f.hasValue = false
+	f.isSet = false
+	f.value = *new(T)
+	f.preParsedValue = ""
+}
+
+// cloneFlag returns a copy of the flag without a value, into which a run of the command is parsed.
+func (f *FlagValue[T]) cloneFlag() FlagDefinition {
+	mirrorMu.RLock()
+	run := *f
+	mirrorMu.RUnlock()
+
+	run.Clear()
+
+	return &run
+}
+
+// mirrorFlag sets the value of the flag to the value of its copy run. mirrorMu must be locked.
+func (f *FlagValue[T]) mirrorFlag(run FlagDefinition) {
+	if r, ok := run.(*FlagValue[T]); ok {
+		f.preParsedValue, f.value, f.hasValue, f.isSet = r.preParsedValue, r.value, r.hasValue, r.isSet
+	}
+}
+
+// Completions returns the values offered when completing the flag.
+// If there is no Complete function, the Choices are offered.
+func (f *FlagValue[T]) Completions(ctx *Context, partial string) []string {
+	if f.Complete == nil {
+		return f.Choices
 	}
 
-	return arr, nil
+	return f.Complete(ctx, partial)
 }
 
-func (f *FlagValue[T]) parseStringArray(flag string) interface{} {
-	var arr []string
+// GetChoices returns the allowed values of the flag.
+func (f *FlagValue[T]) GetChoices() []string {
+	return f.Choices
+}
 
-	arr = strings.Split(flag, ",")
-	for i := range arr {
-		arr[i] = strings.TrimSpace(arr[i])
+// GetDefault returns the default value of the flag formatted as a string, or "" if there is none.
+func (f *FlagValue[T]) GetDefault() string {
+	if f.hasZeroDefault() {
+		return ""
 	}
 
-	return arr
+	return formatFlagValue(f.Default)
 }
 
-func (f *FlagValue[T]) parseBool(flag string) (v interface{}, err error) {
-	return flag == "true", nil
+// ApplyDefault sets the default value if the flag has no value yet.
+func (f *FlagValue[T]) ApplyDefault() {
+	if f.hasValue || f.hasZeroDefault() {
+		return
+	}
+
+	f.value = f.Default
+	f.hasValue = true
 }
 
-// Clear clears the flag.
-func (f *FlagValue[T]) Clear() {
-	f.hasValue = false
-	f.value = *new(T)
-	f.preParsedValue = ""
+func (f *FlagValue[T]) hasZeroDefault() bool {
+	return reflect.ValueOf(&f.Default).Elem().IsZero()
 }
 
-// Flags is an abbreviation for the type `[]Flag`, which adds additional methods for convenient management.
-type Flags []Flag
+// IsRequired returns whether the flag must be set.
+func (f *FlagValue[T]) IsRequired() bool {
+	return f.Required
+}
 
-// GetFlagInt returns the value of the flag with the specified name as an int.
-func (f Flags) GetFlagInt(name string, defaultValue int) int {
-	i := slices.IndexFunc(f, func(flag Flag) bool {
-		return flag.GetName() == name && flag.ValueType() == "int"
-	})
-	if i == -1 {
-		return defaultValue
+// GetEnvVars returns the environment variables the flag is read from.
+func (f *FlagValue[T]) GetEnvVars() []string {
+	return f.EnvVars
+}
+
+func formatFlagValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Sprint(v)
 	}
 
-	p, err := f[i].ParsedValue()
-	if err != nil {
-		return defaultValue
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
 	}
 
-	return p.(int)
+	return strings.Join(parts, ",")
 }
 
-// GetFlagString returns the value of the flag with the specified name as a string.
-func (f Flags) GetFlagString(name string, defaultValue string) string {
-	i := slices.IndexFunc(f, func(flag Flag) bool {
-		return flag.GetName() == name && flag.ValueType() == "string"
-	})
-	if i == -1 {
-		return defaultValue
+// Flags is an abbreviation for the type `[]FlagDefinition`, which adds additional methods for convenient management.
+type Flags []FlagDefinition
+
+// lookupFlag returns the value of the flag with the specified name if it is set and has the type T.
+func lookupFlag[T any](flags Flags, name string) (T, bool) {
+	var zero T
+
+	for _, flag := range flags {
+		if flag.GetName() != name {
+			continue
+		}
+
+		p, err := flag.ParsedValue()
+		if err != nil {
+			continue
+		}
+
+		if v, ok := p.(T); ok {
+			return v, true
+		}
 	}
 
-	p, err := f[i].ParsedValue()
-	if err != nil {
-		return defaultValue
+	return zero, false
+}
+
+// Flag returns the value of the flag with the specified name, and whether it is set and has the type T.
+// It works for any flag type, including the ones added with RegisterFlagType.
+func Flag[T any](ctx *Context, name string) (T, bool) {
+	return lookupFlag[T](ctx.getFlags(), name)
+}
+
+// MustFlag returns the value of the flag with the specified name,
+// or the zero value of T if it is not set or has a different type.
+func MustFlag[T any](ctx *Context, name string) T {
+	v, _ := Flag[T](ctx, name)
+
+	return v
+}
+
+// GetFlagInt returns the value of the flag with the specified name as an int.
+func (f Flags) GetFlagInt(name string, defaultValue int) int {
+	if v, ok := lookupFlag[int](f, name); ok {
+		return v
 	}
 
-	return p.(string)
+	return defaultValue
 }
 
-// GetFlagIntArray returns the value of the flag with the specified name as an array of ints.
-func (f Flags) GetFlagIntArray(name string) []int {
-	i := slices.IndexFunc(f, func(flag Flag) bool {
-		return flag.GetName() == name && flag.ValueType() == "[]int"
-	})
-	if i == -1 {
-		return []int{}
+// GetFlagString returns the value of the flag with the specified name as a string.
+func (f Flags) GetFlagString(name string, defaultValue string) string {
+	if v, ok := lookupFlag[string](f, name); ok {
+		return v
 	}
 
-	p, err := f[i].ParsedValue()
-	if err != nil {
-		return []int{}
+	return defaultValue
+}
+
+// GetFlagIntArray returns the value of the flag with the specified name as an array of ints.
+func (f Flags) GetFlagIntArray(name string) []int {
+	if v, ok := lookupFlag[[]int](f, name); ok {
+		return v
 	}
 
-	return p.([]int)
+	return []int{}
 }
 
 // GetFlagStringArray returns the value of the flag with the specified name as an array of strings.
 func (f Flags) GetFlagStringArray(name string) []string {
-	i := slices.IndexFunc(f, func(flag Flag) bool {
-		return flag.GetName() == name && flag.ValueType() == "[]string"
-	})
-	if i == -1 {
-		return []string{}
+	if v, ok := lookupFlag[[]string](f, name); ok {
+		return v
 	}
 
-	p, err := f[i].ParsedValue()
-	if err != nil {
-		return []string{}
-	}
-
-	return p.([]string)
+	return []string{}
 }
 
 // GetFlagBool returns the value of the flag with the specified name as a bool.
 func (f Flags) GetFlagBool(name string) bool {
-	i := slices.IndexFunc(f, func(flag Flag) bool {
-		return flag.GetName() == name && flag.ValueType() == "bool"
-	})
-	if i == -1 {
-		return false
+	v, _ := lookupFlag[bool](f, name)
+
+	return v
+}
+
+// GetFlagFloat returns the value of the flag with the specified name as a float64.
+func (f Flags) GetFlagFloat(name string, defaultValue float64) float64 {
+	if v, ok := lookupFlag[float64](f, name); ok {
+		return v
 	}
 
-	p, err := f[i].ParsedValue()
-	if err != nil {
-		return false
+	return defaultValue
+}
+
+// GetFlagFloatArray returns the value of the flag with the specified name as an array of float64.
+func (f Flags) GetFlagFloatArray(name string) []float64 {
+	if v, ok := lookupFlag[[]float64](f, name); ok {
+		return v
 	}
 
-	return p.(bool)
+	return []float64{}
+}
+
+// GetFlagDuration returns the value of the flag with the specified name as a time.Duration.
+func (f Flags) GetFlagDuration(name string, defaultValue time.Duration) time.Duration {
+	if v, ok := lookupFlag[time.Duration](f, name); ok {
+		return v
+	}
+
+	return defaultValue
+}
+
+// GetFlagTime returns the value of the flag with the specified name as a time.Time.
+func (f Flags) GetFlagTime(name string, defaultValue time.Time) time.Time {
+	if v, ok := lookupFlag[time.Time](f, name); ok {
+		return v
+	}
+
+	return defaultValue
+}
+
+// GetFlagURL returns the value of the flag with the specified name as a *url.URL, or nil if it is not set.
+func (f Flags) GetFlagURL(name string) *url.URL {
+	v, _ := lookupFlag[*url.URL](f, name)
+
+	return v
+}
+
+// GetFlagIP returns the value of the flag with the specified name as a net.IP, or nil if it is not set.
+func (f Flags) GetFlagIP(name string) net.IP {
+	v, _ := lookupFlag[net.IP](f, name)
+
+	return v
+}
+
+// GetFlagCounter returns the number of times the counter flag with the specified name was given.
+func (f Flags) GetFlagCounter(name string) int {
+	v, _ := lookupFlag[Counter](f, name)
+
+	return int(v)
 }
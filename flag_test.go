@@ -1,8 +1,14 @@
 package replyme
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"slices"
 	"testing"
+	"time"
 )
 
 func newFlagInt() *FlagValue[int] {
@@ -140,6 +146,18 @@ func TestFlagValue_ParseBool(t *testing.T) {
 	if parse != true {
 		t.Fatalf("failed to parse bool: %v", parse)
 	}
+
+	for _, value := range []string{"false", "0", "F"} {
+		parse, err = f.Parse(value)
+		if err != nil || parse != false {
+			t.Fatalf("Parse(%q) = %v, %v, want false", value, parse, err)
+		}
+	}
+
+	_, err = f.Parse("maybe")
+	if !errors.Is(err, ErrorInvalidFlagValue) {
+		t.Fatalf("got %v, want an invalid flag value error", err)
+	}
 }
 
 func TestFlagValue_ParseIntArray(t *testing.T) {
@@ -173,7 +191,7 @@ func TestFlagValue_ParseStringArray(t *testing.T) {
 }
 
 func newFlags() Flags {
-	flags := make([]Flag, 0)
+	flags := make([]FlagDefinition, 0)
 	flags = append(flags, &FlagValue[int]{
 		Name:           "inttest",
 		preParsedValue: "10",
@@ -231,3 +249,192 @@ func TestFlags_GetFlag(t *testing.T) {
 		t.Fatalf("failed to get string array: %v", sa)
 	}
 }
+
+func TestFlagValue_Default(t *testing.T) {
+	f := &FlagValue[int]{
+		Name:    "test",
+		Default: 5,
+	}
+
+	if f.GetDefault() != "5" {
+		t.Fatalf("got default %q, want %q", f.GetDefault(), "5")
+	}
+
+	f.ApplyDefault()
+
+	if d, err := f.ParsedValue(); err != nil || d != 5 {
+		t.Fatalf("failed to apply default: %v, %v", d, err)
+	}
+
+	empty := &FlagValue[[]string]{Name: "test"}
+	empty.ApplyDefault()
+
+	if _, err := empty.ParsedValue(); err == nil {
+		t.Fatal("a zero default must not be applied")
+	}
+
+	slice := &FlagValue[[]string]{Name: "test", Default: []string{"a", "b"}}
+	if slice.GetDefault() != "a,b" {
+		t.Fatalf("got default %q, want %q", slice.GetDefault(), "a,b")
+	}
+}
+
+//nolint:cyclop
+func TestFlagValue_ParseRichTypes(t *testing.T) {
+	flags := Flags{
+		&FlagValue[float64]{Name: "ratio"},
+		&FlagValue[[]float64]{Name: "weights"},
+		&FlagValue[time.Duration]{Name: "timeout"},
+		&FlagValue[time.Time]{Name: "since", Layout: time.DateOnly},
+		&FlagValue[*url.URL]{Name: "endpoint"},
+		&FlagValue[net.IP]{Name: "host"},
+	}
+	values := []string{"0.5", "1, 2.5", "1m30s", "2024-05-01", "https://example.com/api", "10.0.0.1"}
+
+	for i, flag := range flags {
+		if _, err := flag.Parse(values[i]); err != nil {
+			t.Fatalf("failed to parse %s: %v", flag.GetName(), err)
+		}
+
+		if flag.Value() != values[i] {
+			t.Fatalf("got raw value %q, want %q", flag.Value(), values[i])
+		}
+	}
+
+	switch {
+	case flags.GetFlagFloat("ratio", 0) != 0.5:
+		t.Errorf("ratio: got %v", flags.GetFlagFloat("ratio", 0))
+	case !slices.Equal(flags.GetFlagFloatArray("weights"), []float64{1, 2.5}):
+		t.Errorf("weights: got %v", flags.GetFlagFloatArray("weights"))
+	case flags.GetFlagDuration("timeout", 0) != 90*time.Second:
+		t.Errorf("timeout: got %v", flags.GetFlagDuration("timeout", 0))
+	case !flags.GetFlagTime("since", time.Time{}).Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)):
+		t.Errorf("since: got %v", flags.GetFlagTime("since", time.Time{}))
+	case flags.GetFlagURL("endpoint").Host != "example.com":
+		t.Errorf("endpoint: got %v", flags.GetFlagURL("endpoint"))
+	case !flags.GetFlagIP("host").Equal(net.IPv4(10, 0, 0, 1)):
+		t.Errorf("host: got %v", flags.GetFlagIP("host"))
+	}
+
+	if _, err := (&FlagValue[net.IP]{Name: "host"}).Parse("localhost"); !errors.Is(err, ErrorInvalidFlagValue) {
+		t.Errorf("expected an invalid value error, got %v", err)
+	}
+
+	if _, err := (&FlagValue[time.Duration]{Name: "timeout"}).Parse("soon"); err == nil {
+		t.Error("expected a duration parse error")
+	}
+}
+
+func TestFlagValue_Choices(t *testing.T) {
+	f := &FlagValue[string]{
+		Name:    "env",
+		Choices: []string{"dev", "prod"},
+	}
+
+	if _, err := f.Parse("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Parse("stage"); !errors.Is(err, ErrorInvalidFlagChoice) {
+		t.Fatalf("expected an invalid choice error, got %v", err)
+	}
+
+	list := &FlagValue[[]string]{
+		Name:    "envs",
+		Choices: []string{"dev", "prod"},
+	}
+
+	if _, err := list.Parse("dev,prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := list.Parse("dev,stage"); !errors.Is(err, ErrorInvalidFlagChoice) {
+		t.Fatalf("expected an invalid choice error, got %v", err)
+	}
+
+	if !slices.Equal(f.Completions(nil, ""), []string{"dev", "prod"}) {
+		t.Fatalf("the choices must be offered for completion, got %v", f.Completions(nil, ""))
+	}
+}
+
+func TestFlagValue_Parser(t *testing.T) {
+	f := &FlagValue[[]byte]{
+		Name: "data",
+		Parser: func(s string) ([]byte, error) {
+			return []byte(s), nil
+		},
+	}
+
+	if _, err := f.Parse("abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if d, err := f.ParsedValue(); err != nil || string(d.([]byte)) != "abc" {
+		t.Fatalf("the parsed value must be stored: %v, %v", d, err)
+	}
+
+	if f.Type() != FlagTypeCustom {
+		t.Fatalf("got type %v, want FlagTypeCustom", f.Type())
+	}
+}
+
+// sharedFlag is a FlagDefinition of another implementation, which cannot be copied for a run.
+type sharedFlag struct {
+	FlagDefinition
+}
+
+func TestRunFlags_Shared(t *testing.T) {
+	app := &App{Commands: Commands{{
+		Name:  "deploy",
+		Flags: Flags{sharedFlag{&FlagValue[string]{Name: "env"}}},
+	}}}
+
+	ast, err := app.parse("deploy --env prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, release, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err = createCommandFlow(app, ast); !errors.Is(err, ErrorValueInUse) {
+		t.Errorf("got %v, want the flag to be rejected while another run uses it", err)
+	}
+
+	release()
+
+	if _, _, _, err = createCommandFlow(app, ast); err != nil {
+		t.Errorf("got %v after the run released the flag", err)
+	}
+}
+
+func TestFlagDefinition_Minimal(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+
+	app := &App{Commands: Commands{{
+		Name:  "deploy",
+		Flags: Flags{sharedFlag{&FlagValue[int]{Name: "replicas"}}, sharedFlag{&FlagValue[bool]{Name: "force"}}},
+		Action: func(ctx *Context) error {
+			got = fmt.Sprint(ctx.GetFlagInt("replicas", 0), ctx.GetFlagBool("force"))
+
+			return nil
+		},
+	}}}
+
+	buf := &bytes.Buffer{}
+
+	err := fullRunCommand(fullRunCommandParams{"deploy --force --replicas 3", app, make(chan log, 10), nil, buf, buf, func(logMsg) {}, nil, nil, true, nil, nil, 0})
+	if err != nil || got != "3 true" {
+		t.Errorf("got %q, %v, want the flags without the optional methods to be parsed by their ValueType", got, err)
+	}
+
+	if help := helpFlagType(app.Commands[0].Flags[1]); help != "" {
+		t.Errorf("got %q in help, want --force to be shown as a bool flag", help)
+	}
+}
@@ -0,0 +1,108 @@
+package replyme
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FlagConstraint is the kind of constraint a command puts on a group of flags.
+type FlagConstraint uint8
+
+const (
+	// FlagConstraintMutuallyExclusive allows at most one flag of the group to be set.
+	FlagConstraintMutuallyExclusive FlagConstraint = iota
+	// FlagConstraintRequiredTogether requires either all flags of the group to be set or none of them.
+	FlagConstraintRequiredTogether
+	// FlagConstraintOneRequired requires at least one flag of the group to be set.
+	FlagConstraintOneRequired
+)
+
+// flagGroup - a group of flags and the constraint on it.
+type flagGroup struct {
+	constraint FlagConstraint
+	flags      []string
+}
+
+// flagGroups returns the flag groups of the command in the order they are checked.
+func (c *Command) flagGroups() []flagGroup {
+	groups := make([]flagGroup, 0, len(c.MutuallyExclusive)+len(c.RequiredTogether)+len(c.OneRequired))
+
+	for constraint, list := range [][][]string{c.MutuallyExclusive, c.RequiredTogether, c.OneRequired} {
+		for _, flags := range list {
+			groups = append(groups, flagGroup{constraint: FlagConstraint(constraint), flags: flags})
+		}
+	}
+
+	return groups
+}
+
+// validateFlagGroups checks that the flag groups of the command refer only to the flags it can read:
+// its own, the persistent flags of its parents and the global flags.
+func validateFlagGroups(command *Command, parent []string, flags Flags) error {
+	for _, group := range command.flagGroups() {
+		for _, name := range group.flags {
+			if findFlag(flags, name) == nil {
+				return newErrorInvalidFlagGroup(commandPath(append(slices.Clone(parent), command.Name)),
+					fmt.Sprintf(L(i18n_flag_group_unknown_flag), flagDisplayName(name)))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFlagGroups checks the flag groups of the command against the flags it can read.
+// A flag counts as set if it was given on the command line, in the environment or in a config file,
+// not by its default.
+func checkFlagGroups(command string, groups []flagGroup, flags Flags) error {
+	for _, group := range groups {
+		var set, unset []string
+
+		for _, name := range group.flags {
+			if flag := findFlag(flags, name); flag != nil && flagIsSet(flag) {
+				set = append(set, name)
+			} else {
+				unset = append(unset, name)
+			}
+		}
+
+		var failed bool
+
+		switch group.constraint {
+		case FlagConstraintMutuallyExclusive:
+			failed = len(set) > 1
+		case FlagConstraintRequiredTogether:
+			failed = len(set) > 0 && len(unset) > 0
+		case FlagConstraintOneRequired:
+			failed = len(set) == 0
+		}
+
+		if failed {
+			return &FlagGroupError{Command: command, Constraint: group.constraint, Flags: group.flags, Set: set}
+		}
+	}
+
+	return nil
+}
+
+// describe returns the localized description of the constraint on the flags.
+func (c FlagConstraint) describe(flags []string) string {
+	names := make([]string, len(flags))
+	for i, name := range flags {
+		names[i] = flagDisplayName(name)
+	}
+
+	return fmt.Sprintf(L(c.message()), strings.Join(names, ", "))
+}
+
+func (c FlagConstraint) message() string {
+	switch c {
+	case FlagConstraintRequiredTogether:
+		return i18n_flag_group_required_together
+	case FlagConstraintOneRequired:
+		return i18n_flag_group_one_required
+	default:
+		return i18n_flag_group_mutually_exclusive
+	}
+}
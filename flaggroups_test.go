@@ -0,0 +1,92 @@
+package replyme
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckFlagGroups(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newApp := func() *App {
+		return &App{Commands: Commands{{
+			Name: "login",
+			Flags: Flags{
+				&FlagValue[string]{Name: "file"},
+				&FlagValue[string]{Name: "url"},
+				&FlagValue[string]{Name: "user"},
+				&FlagValue[string]{Name: "password", Default: "secret"},
+			},
+			MutuallyExclusive: [][]string{{"file", "url"}},
+			RequiredTogether:  [][]string{{"user", "password"}},
+			OneRequired:       [][]string{{"file", "url"}},
+		}}}
+	}
+
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"login --file a", nil},
+		{"login --url a --user u --password p", nil},
+		{"login --file a --url b", ErrorMutuallyExclusiveFlags},
+		{"login --file a --user u", ErrorRequiredTogetherFlags},
+		{"login --user u --password p", ErrorOneRequiredFlag},
+	}
+
+	for _, tt := range tests {
+		app := newApp()
+
+		ast, err := app.parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, _, err = createCommandFlow(app, ast)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%q returns %v, want %v", tt.input, err, tt.want)
+		}
+	}
+
+	help, err := helpCommand(newApp().Commands[0], nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(help, L(i18n_help_constraints)) ||
+		!strings.Contains(help, FlagConstraintMutuallyExclusive.describe([]string{"file", "url"})) {
+		t.Fatalf("the help must show the constraints:\n%s", help)
+	}
+}
+
+func TestValidateFlagGroups(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := Commands{{
+		Name:            "deploy",
+		PersistentFlags: Flags{&FlagValue[string]{Name: "env"}},
+		Subcommands: Commands{{
+			Name:              "start",
+			Flags:             Flags{&FlagValue[bool]{Name: "force"}},
+			MutuallyExclusive: [][]string{{"force", "env", "verbose"}},
+		}},
+	}}
+
+	if err = validateCommands(commands, nil, Flags{&FlagValue[bool]{Name: "verbose"}}, parseOptions{}); err != nil {
+		t.Errorf("got %v, want the inherited and global flags to be accepted in groups", err)
+	}
+
+	commands[0].Subcommands[0].MutuallyExclusive = [][]string{{"force", "dry-run"}}
+
+	err = validateCommands(commands, nil, nil, parseOptions{})
+	if !errors.Is(err, ErrorInvalidFlagGroup) || !strings.Contains(err.Error(), "--dry-run") {
+		t.Errorf("got %v, want the unknown flag of the group to be rejected", err)
+	}
+}
@@ -0,0 +1,298 @@
+package replyme
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlagKind describes how a flag is written on the command line.
+type FlagKind uint8
+
+const (
+	// FlagKindValue is a flag that takes a value: `--name value` or `--name=value`.
+	FlagKindValue FlagKind = iota
+	// FlagKindBool is a flag that does not take a value: `--name` means true.
+	FlagKindBool
+	// FlagKindSlice is a flag that takes a comma-separated list of values.
+	// It can be repeated, and the values of all occurrences are accumulated.
+	FlagKindSlice
+	// FlagKindCounter is a flag that does not take a value and counts its occurrences: `-vvv` is 3.
+	FlagKindCounter
+)
+
+// Counter is the type of flags that count how many times they were given, e.g. `-vvv` for verbosity.
+// An explicit value like `--verbose=2` sets the counter.
+type Counter int
+
+func (k FlagKind) takesValue() bool {
+	return k == FlagKindValue || k == FlagKindSlice
+}
+
+func (k FlagKind) repeatable() bool {
+	return k == FlagKindSlice || k == FlagKindCounter
+}
+
+// flagParseOptions are the settings of a particular flag that affect parsing.
+type flagParseOptions struct {
+	Layout string
+}
+
+type flagTypeInfo struct {
+	flagType FlagType
+	kind     FlagKind
+	name     string
+	// i18n identifier of the type name shown in help, empty for custom types
+	label string
+	parse func(opts flagParseOptions, s string) (interface{}, error)
+	// creates a flag or an argument of this type for a struct field, see CommandFor
+	newFlag func(field fieldSpec) (FlagDefinition, error)
+	newArg  func(field fieldSpec, variadic bool) (Arg, error)
+}
+
+type flagTypeRegistry struct {
+	mu     sync.RWMutex
+	byType map[reflect.Type]*flagTypeInfo
+	byFlag map[FlagType]*flagTypeInfo
+	next   FlagType
+}
+
+var flagTypes = newFlagTypeRegistry()
+
+//nolint:funlen
+func newFlagTypeRegistry() *flagTypeRegistry {
+	r := &flagTypeRegistry{
+		byType: map[reflect.Type]*flagTypeInfo{},
+		byFlag: map[FlagType]*flagTypeInfo{},
+		next:   FlagTypeCustom + 1,
+	}
+
+	registerFlagType(r, FlagTypeInt, FlagKindValue, i18n_help_flag_type_int,
+		func(_ flagParseOptions, s string) (int, error) {
+			return strconv.Atoi(s)
+		})
+	registerFlagType(r, FlagTypeString, FlagKindValue, i18n_help_flag_type_string,
+		func(_ flagParseOptions, s string) (string, error) {
+			return s, nil
+		})
+	registerFlagType(r, FlagTypeIntArray, FlagKindSlice, i18n_help_flag_type_int_array,
+		func(_ flagParseOptions, s string) ([]int, error) {
+			return parseList(s, strconv.Atoi)
+		})
+	registerFlagType(r, FlagTypeStringArray, FlagKindSlice, i18n_help_flag_type_string_array,
+		func(_ flagParseOptions, s string) ([]string, error) {
+			return splitList(s), nil
+		})
+	registerFlagType(r, FlagTypeBool, FlagKindBool, i18n_help_flag_type_bool,
+		func(_ flagParseOptions, s string) (bool, error) {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return false, newErrorInvalidFlagValue(s, "bool")
+			}
+
+			return b, nil
+		})
+	registerFlagType(r, FlagTypeCounter, FlagKindCounter, i18n_help_flag_type_int,
+		func(_ flagParseOptions, s string) (Counter, error) {
+			n, err := strconv.Atoi(s)
+
+			return Counter(n), err
+		})
+	registerFlagType(r, FlagTypeFloat, FlagKindValue, i18n_help_flag_type_float,
+		func(_ flagParseOptions, s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+	registerFlagType(r, FlagTypeFloatArray, FlagKindSlice, i18n_help_flag_type_float_array,
+		func(_ flagParseOptions, s string) ([]float64, error) {
+			return parseList(s, func(s string) (float64, error) {
+				return strconv.ParseFloat(s, 64)
+			})
+		})
+	registerFlagType(r, FlagTypeDuration, FlagKindValue, i18n_help_flag_type_duration,
+		func(_ flagParseOptions, s string) (time.Duration, error) {
+			return time.ParseDuration(s)
+		})
+	registerFlagType(r, FlagTypeTime, FlagKindValue, i18n_help_flag_type_time,
+		func(opts flagParseOptions, s string) (time.Time, error) {
+			layout := opts.Layout
+			if layout == "" {
+				layout = time.RFC3339
+			}
+
+			return time.Parse(layout, s)
+		})
+	registerFlagType(r, FlagTypeURL, FlagKindValue, i18n_help_flag_type_url,
+		func(_ flagParseOptions, s string) (*url.URL, error) {
+			return url.Parse(s)
+		})
+	registerFlagType(r, FlagTypeIP, FlagKindValue, i18n_help_flag_type_ip,
+		func(_ flagParseOptions, s string) (net.IP, error) {
+			ip := net.ParseIP(strings.TrimSpace(s))
+			if ip == nil {
+				return nil, newErrorInvalidFlagValue(s, "IP")
+			}
+
+			return ip, nil
+		})
+
+	return r
+}
+
+func registerFlagType[T any](
+	r *flagTypeRegistry,
+	flagType FlagType,
+	kind FlagKind,
+	label string,
+	parse func(opts flagParseOptions, s string) (T, error),
+) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	info := &flagTypeInfo{
+		flagType: flagType,
+		kind:     kind,
+		name:     t.String(),
+		label:    label,
+		parse: func(opts flagParseOptions, s string) (interface{}, error) {
+			return parse(opts, s)
+		},
+		newFlag: func(field fieldSpec) (FlagDefinition, error) {
+			flag := &FlagValue[T]{
+				Name:     field.name,
+				Alias:    field.alias,
+				Usage:    field.usage,
+				Required: field.required,
+				EnvVars:  field.envVars,
+				Choices:  field.choices,
+			}
+
+			if field.hasDefault {
+				v, err := parse(flagParseOptions{}, field.defaultValue)
+				if err != nil {
+					return nil, err
+				}
+
+				flag.Default = v
+			}
+
+			return flag, nil
+		},
+		newArg: func(field fieldSpec, variadic bool) (Arg, error) {
+			arg := &ArgumentValue[T]{
+				Name:     field.name,
+				Usage:    field.usage,
+				Optional: field.optional,
+				Variadic: variadic,
+			}
+
+			if field.hasDefault {
+				v, err := parse(flagParseOptions{}, field.defaultValue)
+				if err != nil {
+					return nil, err
+				}
+
+				arg.Default = v
+			}
+
+			return arg, nil
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byType[t] = info
+	r.byFlag[flagType] = info
+}
+
+// RegisterFlagType registers a parser for flags of type T, so that `FlagValue[T]` works in the parser,
+// help and Flag like the built-in types. Registering a type again replaces its parser.
+func RegisterFlagType[T any](parse func(s string) (T, error), kind FlagKind) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	flagTypes.mu.Lock()
+	flagType := flagTypes.next
+	if info, ok := flagTypes.byType[t]; ok {
+		flagType = info.flagType
+	} else {
+		flagTypes.next++
+	}
+	flagTypes.mu.Unlock()
+
+	registerFlagType(flagTypes, flagType, kind, "", func(_ flagParseOptions, s string) (T, error) {
+		return parse(s)
+	})
+}
+
+func (r *flagTypeRegistry) lookup(t reflect.Type) *flagTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byType[t]
+}
+
+// lookupName returns the type whose name is the ValueType of a flag, e.g. "[]int".
+func (r *flagTypeRegistry) lookupName(name string) *flagTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, info := range r.byType {
+		if info.name == name {
+			return info
+		}
+	}
+
+	return nil
+}
+
+func (r *flagTypeRegistry) lookupFlagType(flagType FlagType) *flagTypeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byFlag[flagType]
+}
+
+// Kind returns how the flags of this type are written on the command line.
+func (t FlagType) Kind() FlagKind {
+	if info := flagTypes.lookupFlagType(t); info != nil {
+		return info.kind
+	}
+
+	return FlagKindValue
+}
+
+// helpLabel returns the name of the type shown in help.
+func (t FlagType) helpLabel(valueType string) string {
+	info := flagTypes.lookupFlagType(t)
+	if info == nil || info.label == "" {
+		return "[" + valueType + "]"
+	}
+
+	return L(info.label)
+}
+
+func splitList(s string) []string {
+	arr := strings.Split(s, ",")
+	for i := range arr {
+		arr[i] = strings.TrimSpace(arr[i])
+	}
+
+	return arr
+}
+
+func parseList[T any](s string, parse func(s string) (T, error)) ([]T, error) {
+	arr := []T{}
+
+	for _, part := range splitList(s) {
+		v, err := parse(part)
+		if err != nil {
+			return nil, err
+		}
+
+		arr = append(arr, v)
+	}
+
+	return arr, nil
+}
@@ -0,0 +1,94 @@
+package replyme
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testLevel int
+
+func parseTestLevel(s string) (testLevel, error) {
+	switch s {
+	case "low":
+		return 1, nil
+	case "high":
+		return 2, nil
+	default:
+		return 0, errors.New("unknown level")
+	}
+}
+
+func TestRegisterFlagType(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &FlagValue[testLevel]{Name: "level"}
+
+	if _, err := f.Parse("low"); !errors.Is(err, ErrorUnknownFlagType) {
+		t.Fatalf("an unregistered type must not be parsed, got %v", err)
+	}
+
+	RegisterFlagType(parseTestLevel, FlagKindValue)
+
+	if f.Type() <= FlagTypeCustom || f.Type().Kind() != FlagKindValue {
+		t.Fatalf("got type %v, want a registered custom type", f.Type())
+	}
+
+	RegisterFlagType(parseTestLevel, FlagKindValue)
+
+	if f.Type() != (&FlagValue[testLevel]{}).Type() {
+		t.Fatal("registering a type again must keep its FlagType")
+	}
+
+	cmd := &Command{Name: "scan", Flags: Flags{f}}
+	app := &App{Commands: Commands{cmd}}
+
+	ast, err := app.parse("scan --level high")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, _, _, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := createPreContext(flow[0], ast)
+
+	if v, ok := Flag[testLevel](ctx, "level"); !ok || v != 2 {
+		t.Fatalf("got %v, %v, want 2, true", v, ok)
+	}
+
+	if _, ok := Flag[string](ctx, "level"); ok {
+		t.Fatal("a flag of a different type must not be returned")
+	}
+
+	if v := MustFlag[testLevel](ctx, "missing"); v != 0 {
+		t.Fatalf("got %v, want the zero value", v)
+	}
+
+	help, err := helpCommand(cmd, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(help, "replyme.testLevel") {
+		t.Fatalf("the help must show the type of the flag:\n%s", help)
+	}
+}
+
+func TestFlagType_Kind(t *testing.T) {
+	switch {
+	case FlagTypeBool.Kind() != FlagKindBool:
+		t.Error("bool flags must not take a value")
+	case FlagTypeStringArray.Kind() != FlagKindSlice:
+		t.Error("[]string flags must be lists")
+	case FlagTypeDuration.Kind() != FlagKindValue:
+		t.Error("time.Duration flags must take a value")
+	case FlagTypeCustom.Kind() != FlagKindValue:
+		t.Error("custom flags must take a value")
+	}
+}
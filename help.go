@@ -3,6 +3,7 @@ package replyme
 import (
 	"bytes"
 	"github.com/charmbracelet/lipgloss"
+	"slices"
 	"strings"
 	"text/template"
 )
@@ -12,97 +13,170 @@ type helpStruct struct {
 	Authors     []string
 	License     string
 	Usage       string
+	UsageLine   string
+	Aliases     []string
 	Flags       []helpFlagsStruct
+	Inherited   []helpFlagsStruct
+	Constraints []string
 	Arguments   []helpArgumentsStruct
 	Subcommands []helpSubcommandsStruct
 	I18n        helpI18nStruct
 }
 
+// helpFlagList is the data of the "flags" template: a section of the flags and the labels of the help.
+type helpFlagList struct {
+	Flags []helpFlagsStruct
+	I18n  helpI18nStruct
+}
+
+// FlagList returns the flags with the labels of the help, which the "flags" template renders.
+func (h helpStruct) FlagList(flags []helpFlagsStruct) helpFlagList {
+	return helpFlagList{Flags: flags, I18n: h.I18n}
+}
+
 type helpFlagsStruct struct {
-	Name  string
-	Usage string
-	Type  string
-	Alias string
+	Name       string
+	Usage      string
+	Type       string
+	Alias      string
+	Default    string
+	EnvVars    []string
+	Required   bool
+	Repeatable bool
 }
 
 type helpArgumentsStruct struct {
-	Name  string
-	Usage string
+	Name     string
+	Usage    string
+	Default  string
+	Optional bool
+	Variadic bool
 }
 
 type helpSubcommandsStruct struct {
-	Name  string
-	Usage string
+	Name    string
+	Aliases []string
+	Usage   string
 }
 
 type helpI18nStruct struct {
 	Authors     string
+	UsageLine   string
+	Aliases     string
 	Subcommands string
 	Flags       string
+	Inherited   string
+	Constraints string
 	Arguments   string
 	License     string
+	Default     string
+	Env         string
+	Required    string
+	Repeatable  string
+	Optional    string
 }
 
-var HelpCommandTemplate = `{{ Bold .Name }} - {{ .Usage }}
+var HelpCommandTemplate = `{{ define "flags" }}{{ range .Flags }}  --{{ Blue .Name }}{{ if .Alias }}(-{{ Gray .Alias }}){{ end }}{{ Cyan .Type}} - {{ .Usage }}{{ if .Required }} {{ Bold (print "(" $.I18n.Required ")") }}{{ end }}{{ if .Repeatable }} {{ Gray (print "(" $.I18n.Repeatable ")") }}{{ end }}{{ if .Default }} {{ Gray (print "[" $.I18n.Default ": " .Default "]") }}{{ end }}{{ if .EnvVars }} {{ Gray (print "[" $.I18n.Env ": " (StringsJoin .EnvVars ", ") "]") }}{{ end }}
+{{ end }}{{ end }}{{ Bold .Name }} - {{ .Usage }}
 
-{{ if .Authors }}{{ Bold .I18n.Authors }}:
+{{ if .UsageLine }}{{ Bold .I18n.UsageLine }}:
+  {{ .UsageLine }}
+{{ end }}{{ if .Aliases }}{{ Bold .I18n.Aliases }}:
+  {{ StringsJoin .Aliases ", " }}
+{{ end }}{{ if .Authors }}{{ Bold .I18n.Authors }}:
   {{ StringsJoin .Authors ", " }}
 {{ end }}{{ if .License }}{{ Bold .I18n.License }}:
   {{ .License }}
 {{ end }}{{ if .Subcommands }}{{ Bold .I18n.Subcommands }}:
-{{ range .Subcommands }}  {{ Green .Name }} - {{ .Usage }}
+{{ range .Subcommands }}  {{ Green .Name }}{{ if .Aliases }} ({{ StringsJoin .Aliases ", " }}){{ end }} - {{ .Usage }}
 {{ end }}{{ end }}{{ if .Flags }}{{ Bold .I18n.Flags}}:
-{{ range .Flags }}  --{{ Blue .Name }}{{ if .Alias }}(-{{ Gray .Alias }}){{ end }}{{ Cyan .Type}} - {{ .Usage }}
+{{ template "flags" ($.FlagList .Flags) }}{{ end }}{{ if .Inherited }}{{ Bold .I18n.Inherited }}:
+{{ template "flags" ($.FlagList .Inherited) }}{{ end }}{{ if .Constraints }}{{ Bold .I18n.Constraints }}:
+{{ range .Constraints }}  {{ . }}
 {{ end }}{{ end }}{{ if .Arguments }}{{ Bold .I18n.Arguments }}:
-{{ range .Arguments }}  {{ Purple .Name }} - {{ .Usage }}
+{{ range .Arguments }}  {{ Purple .Name }}{{ if .Variadic }}...{{ end }} - {{ .Usage }}{{ if .Optional }} {{ Gray (print "(" $.I18n.Optional ")") }}{{ end }}{{ if .Default }} {{ Gray (print "[" $.I18n.Default ": " .Default "]") }}{{ end }}
 {{ end }}{{ end }}`
 
-func buildHelpFlags(command *Command) []helpFlagsStruct {
-	if command.Flags == nil {
+func buildHelpFlags(commandFlags Flags, envPrefix string) []helpFlagsStruct {
+	if len(commandFlags) == 0 {
 		return nil
 	}
 
-	flags := make([]helpFlagsStruct, len(command.Flags))
-	for i, flag := range command.Flags {
+	flags := make([]helpFlagsStruct, len(commandFlags))
+	for i, flag := range commandFlags {
 		flags[i] = helpFlagsStruct{
-			Name:  flag.GetName(),
-			Usage: flag.GetUsage(),
-			Alias: flag.GetAlias(),
+			Name:       flag.GetName(),
+			Usage:      flag.GetUsage(),
+			Alias:      flag.GetAlias(),
+			Default:    flagDefault(flag),
+			EnvVars:    flagEnvVars(flag, envPrefix),
+			Required:   flagRequired(flag),
+			Repeatable: kindOfFlag(flag).repeatable(),
 		}
 
-		switch flag.ValueType() {
-		case "string":
-			flags[i].Type = "=" + L(i18n_help_flag_type_string)
-		case "bool":
-			flags[i].Type = ""
-		case "int":
-			flags[i].Type = "=" + L(i18n_help_flag_type_int)
-		case "[]string":
-			flags[i].Type = "=" + L(i18n_help_flag_type_string_array)
-		case "[]int":
-			flags[i].Type = "=" + L(i18n_help_flag_type_int_array)
-		}
+		flags[i].Type = helpFlagType(flag)
 	}
 
 	return flags
 }
 
+func helpFlagType(flag FlagDefinition) string {
+	if choices := flagChoices(flag); len(choices) > 0 {
+		return "=[" + strings.Join(choices, "|") + "]"
+	}
+
+	if !kindOfFlag(flag).takesValue() {
+		return ""
+	}
+
+	return "=" + typeOfFlag(flag).helpLabel(flag.ValueType())
+}
+
+func buildHelpConstraints(command *Command) []string {
+	groups := command.flagGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	constraints := make([]string, len(groups))
+	for i, group := range groups {
+		constraints[i] = group.constraint.describe(group.flags)
+	}
+
+	return constraints
+}
+
 func buildHelpArguments(command *Command) []helpArgumentsStruct {
-	if command.Arguments == nil {
+	arguments := command.arguments()
+	if len(arguments) == 0 {
 		return nil
 	}
 
-	args := make([]helpArgumentsStruct, len(command.Arguments))
-	for i, arg := range command.Arguments {
+	args := make([]helpArgumentsStruct, len(arguments))
+	for i, arg := range arguments {
 		args[i] = helpArgumentsStruct{
-			Name:  arg.Name,
-			Usage: arg.Usage,
+			Name:     arg.GetName(),
+			Usage:    arg.GetUsage(),
+			Default:  arg.GetDefault(),
+			Optional: arg.IsOptional(),
+			Variadic: arg.IsVariadic(),
 		}
 	}
 
 	return args
 }
 
+// helpUsageLine returns how the command is written with its arguments, e.g. `copy <src>... [dst]`,
+// or "" if the command has no arguments.
+func helpUsageLine(name string, command *Command) string {
+	args := command.arguments()
+	if len(args) == 0 {
+		return ""
+	}
+
+	return name + " " + argumentsUsage(args)
+}
+
 func buildHelpSubcommands(command *Command) []helpSubcommandsStruct {
 	if command.Subcommands == nil {
 		return nil
@@ -111,8 +185,9 @@ func buildHelpSubcommands(command *Command) []helpSubcommandsStruct {
 	subcommands := make([]helpSubcommandsStruct, len(command.Subcommands))
 	for i, subcommand := range command.Subcommands {
 		subcommands[i] = helpSubcommandsStruct{
-			Name:  subcommand.Name,
-			Usage: subcommand.Usage,
+			Name:    subcommand.Name,
+			Aliases: subcommand.Aliases,
+			Usage:   subcommand.Usage,
 		}
 	}
 
@@ -127,8 +202,9 @@ func buildHelpCommands(app *App) []helpSubcommandsStruct {
 	commands := make([]helpSubcommandsStruct, len(app.Commands))
 	for i, subcommand := range app.Commands {
 		commands[i] = helpSubcommandsStruct{
-			Name:  subcommand.Name,
-			Usage: subcommand.Usage,
+			Name:    subcommand.Name,
+			Aliases: subcommand.Aliases,
+			Usage:   subcommand.Usage,
 		}
 	}
 
@@ -138,10 +214,19 @@ func buildHelpCommands(app *App) []helpSubcommandsStruct {
 func buildHelpI18n() helpI18nStruct {
 	return helpI18nStruct{
 		Authors:     L(i18n_help_authors),
+		UsageLine:   L(i18n_help_usage),
+		Aliases:     L(i18n_help_aliases),
 		Subcommands: L(i18n_help_subcommands),
 		Flags:       L(i18n_help_flags),
+		Inherited:   L(i18n_help_inherited_flags),
+		Constraints: L(i18n_help_constraints),
 		Arguments:   L(i18n_help_arguments),
 		License:     L(i18n_help_license),
+		Default:     L(i18n_help_flag_default),
+		Env:         L(i18n_help_flag_env),
+		Required:    L(i18n_help_flag_required),
+		Repeatable:  L(i18n_help_flag_repeatable),
+		Optional:    L(i18n_help_argument_optional),
 	}
 }
 
@@ -162,13 +247,24 @@ func createTemplate() {
 	}
 }
 
-func helpCommand(command *Command) (string, error) {
+// helpCommand renders the help of the command with the specified command tree. inherited are the persistent flags
+// of its parents and the global flags of the application, shown in a separate section.
+func helpCommand(command *Command, tree []string, inherited Flags, envPrefix string) (string, error) {
 	createTemplate()
 
+	name := command.Name
+	if len(tree) > 0 {
+		name = strings.Join(tree, " ")
+	}
+
 	t := helpStruct{
-		Name:        command.Name,
+		Name:        name,
 		Usage:       command.Usage,
-		Flags:       buildHelpFlags(command),
+		UsageLine:   helpUsageLine(name, command),
+		Aliases:     command.Aliases,
+		Flags:       buildHelpFlags(append(slices.Clone(command.Flags), command.PersistentFlags...), envPrefix),
+		Inherited:   buildHelpFlags(inherited, envPrefix),
+		Constraints: buildHelpConstraints(command),
 		Arguments:   buildHelpArguments(command),
 		Subcommands: buildHelpSubcommands(command),
 		I18n:        buildHelpI18n(),
@@ -198,6 +294,7 @@ func helpApp(app *App) (string, error) {
 		Authors:     app.Authors,
 		License:     app.License,
 		Subcommands: buildHelpCommands(app),
+		Flags:       buildHelpFlags(app.GlobalFlags, app.EnvPrefix),
 		I18n:        buildHelpI18n(),
 	}
 
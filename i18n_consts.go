@@ -4,33 +4,92 @@
 package replyme
 
 const (
-	i18n_cmd_input_command           string = "cmd_input_command"
-	i18n_cmd_input_running           string = "cmd_input_running"
-	i18n_confirm_view_yes            string = "confirm_view_yes"
-	i18n_confirm_view_no             string = "confirm_view_no"
-	i18n_locales_message_notfound    string = "locales_message_notfound"
-	i18n_inputfile_placeholder       string = "inputfile_placeholder"
-	i18n_inputfile_fullpath_error    string = "inputfile_fullpath_error"
-	i18n_inputfile_file_notfound     string = "inputfile_file_notfound"
-	i18n_inputfile_extension_error   string = "inputfile_extension_error"
-	i18n_inputfile_size_error        string = "inputfile_size_error"
-	i18n_inputfile_read_error        string = "inputfile_read_error"
-	i18n_inputfile_success           string = "inputfile_success"
-	i18n_inputfile_path_error        string = "inputfile_path_error"
-	i18n_parser_empty_command        string = "parser_empty_command"
-	i18n_inputint_placeholder        string = "inputint_placeholder"
-	i18n_help_flags                  string = "help_flags"
-	i18n_help_subcommands            string = "help_subcommands"
-	i18n_help_arguments              string = "help_arguments"
-	i18n_help_authors                string = "help_authors"
-	i18n_help_license                string = "help_license"
-	i18n_help_flag_type_string       string = "help_flag_type_string"
-	i18n_help_flag_type_int          string = "help_flag_type_int"
-	i18n_help_flag_type_bool         string = "help_flag_type_bool"
-	i18n_help_flag_type_string_array string = "help_flag_type_string_array"
-	i18n_help_flag_type_int_array    string = "help_flag_type_int_array"
-	i18n_app_help_usage              string = "app_help_usage"
-	i18n_tui_selectone_item          string = "tui_selectone_item"
-	i18n_tui_selectone_items         string = "tui_selectone_items"
-	i18n_tui_inputFile_err           string = "tui_inputFile_err"
+	i18n_cmd_input_command             string = "cmd_input_command"
+	i18n_cmd_input_running             string = "cmd_input_running"
+	i18n_confirm_view_yes              string = "confirm_view_yes"
+	i18n_confirm_view_no               string = "confirm_view_no"
+	i18n_locales_message_notfound      string = "locales_message_notfound"
+	i18n_inputfile_placeholder         string = "inputfile_placeholder"
+	i18n_inputfile_fullpath_error      string = "inputfile_fullpath_error"
+	i18n_inputfile_file_notfound       string = "inputfile_file_notfound"
+	i18n_inputfile_extension_error     string = "inputfile_extension_error"
+	i18n_inputfile_size_error          string = "inputfile_size_error"
+	i18n_inputfile_read_error          string = "inputfile_read_error"
+	i18n_inputfile_success             string = "inputfile_success"
+	i18n_inputfile_path_error          string = "inputfile_path_error"
+	i18n_parser_empty_command          string = "parser_empty_command"
+	i18n_inputint_placeholder          string = "inputint_placeholder"
+	i18n_help_flags                    string = "help_flags"
+	i18n_help_subcommands              string = "help_subcommands"
+	i18n_help_arguments                string = "help_arguments"
+	i18n_help_authors                  string = "help_authors"
+	i18n_help_license                  string = "help_license"
+	i18n_help_flag_type_string         string = "help_flag_type_string"
+	i18n_help_flag_type_int            string = "help_flag_type_int"
+	i18n_help_flag_type_bool           string = "help_flag_type_bool"
+	i18n_help_flag_type_string_array   string = "help_flag_type_string_array"
+	i18n_help_flag_type_int_array      string = "help_flag_type_int_array"
+	i18n_app_help_usage                string = "app_help_usage"
+	i18n_tui_selectone_item            string = "tui_selectone_item"
+	i18n_tui_selectone_items           string = "tui_selectone_items"
+	i18n_tui_inputFile_err             string = "tui_inputFile_err"
+	i18n_app_completion_usage          string = "app_completion_usage"
+	i18n_help_flag_default             string = "help_flag_default"
+	i18n_help_flag_env                 string = "help_flag_env"
+	i18n_help_flag_required            string = "help_flag_required"
+	i18n_help_flag_type_float          string = "help_flag_type_float"
+	i18n_help_flag_type_float_array    string = "help_flag_type_float_array"
+	i18n_help_flag_type_duration       string = "help_flag_type_duration"
+	i18n_help_flag_type_time           string = "help_flag_type_time"
+	i18n_help_flag_type_url            string = "help_flag_type_url"
+	i18n_help_flag_type_ip             string = "help_flag_type_ip"
+	i18n_help_flag_repeatable          string = "help_flag_repeatable"
+	i18n_help_inherited_flags          string = "help_inherited_flags"
+	i18n_help_aliases                  string = "help_aliases"
+	i18n_did_you_mean                  string = "did_you_mean"
+	i18n_flag_group_mutually_exclusive string = "flag_group_mutually_exclusive"
+	i18n_flag_group_required_together  string = "flag_group_required_together"
+	i18n_flag_group_one_required       string = "flag_group_one_required"
+	i18n_flag_group_unknown_flag       string = "flag_group_unknown_flag"
+	i18n_help_constraints              string = "help_constraints"
+	i18n_help_usage                    string = "help_usage"
+	i18n_help_argument_optional        string = "help_argument_optional"
+	i18n_app_config_usage              string = "app_config_usage"
+	i18n_app_config_show_usage         string = "app_config_show_usage"
+	i18n_app_config_show_command_usage string = "app_config_show_command_usage"
+	i18n_config_source_env             string = "config_source_env"
+	i18n_config_source_file            string = "config_source_file"
+	i18n_config_source_default         string = "config_source_default"
+	i18n_config_source_unset           string = "config_source_unset"
+	i18n_app_set_usage                 string = "app_set_usage"
+	i18n_app_set_assignments_usage     string = "app_set_assignments_usage"
+	i18n_app_unset_usage               string = "app_unset_usage"
+	i18n_app_unset_names_usage         string = "app_unset_names_usage"
+	i18n_app_vars_usage                string = "app_vars_usage"
+	i18n_app_source_usage              string = "app_source_usage"
+	i18n_app_source_file_usage         string = "app_source_file_usage"
+	i18n_app_source_continue_usage     string = "app_source_continue_usage"
+	i18n_app_source_echo_usage         string = "app_source_echo_usage"
+	i18n_app_alias_usage               string = "app_alias_usage"
+	i18n_app_alias_definitions_usage   string = "app_alias_definitions_usage"
+	i18n_app_unalias_usage             string = "app_unalias_usage"
+	i18n_app_unalias_names_usage       string = "app_unalias_names_usage"
+	i18n_job_state_running             string = "job_state_running"
+	i18n_job_state_done                string = "job_state_done"
+	i18n_job_state_failed              string = "job_state_failed"
+	i18n_job_state_killed              string = "job_state_killed"
+	i18n_jobs_status                   string = "jobs_status"
+	i18n_app_jobs_usage                string = "app_jobs_usage"
+	i18n_app_fg_usage                  string = "app_fg_usage"
+	i18n_app_fg_job_usage              string = "app_fg_job_usage"
+	i18n_app_wait_usage                string = "app_wait_usage"
+	i18n_app_wait_jobs_usage           string = "app_wait_jobs_usage"
+	i18n_app_kill_usage                string = "app_kill_usage"
+	i18n_app_kill_jobs_usage           string = "app_kill_jobs_usage"
+	i18n_cmd_cancel_hint               string = "cmd_cancel_hint"
+	i18n_cmd_abandoned                 string = "cmd_abandoned"
+	i18n_app_timeout_usage             string = "app_timeout_usage"
+	i18n_cmd_timeout                   string = "cmd_timeout"
+	i18n_commands_top_level            string = "commands_top_level"
+	i18n_arguments_one_variadic        string = "arguments_one_variadic"
 )
@@ -0,0 +1,171 @@
+package replyme
+
+import (
+	"slices"
+	"strings"
+)
+
+// maxCallDepth limits the nesting of function calls, so that a function that calls itself
+// without an end fails instead of crashing the application.
+const maxCallDepth = 100
+
+// interpreter runs the statements of a command line or script.
+type interpreter struct {
+	// run runs a pipeline, p.command is its text
+	run func(p fullRunCommandParams) error
+	// start starts the statement of `&` in the background, nil if background jobs are not supported
+	start func(p fullRunCommandParams, s statement) error
+	depth int
+	// The aliases that are being expanded, which are not expanded again
+	expanding map[string]bool
+}
+
+// execList runs the statements one after another and returns the error of the last one.
+// It stops if p.ctx is cancelled.
+func (in *interpreter) execList(p fullRunCommandParams, list []statement) error {
+	var err error
+
+	for _, s := range list {
+		if ctxErr := p.context().Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		err = in.exec(p, s)
+	}
+
+	return err
+}
+
+// exec runs the statement. The error of a command is its exit status:
+// the condition of `if` is true and `&&` goes on if it is nil. Nothing runs once p.ctx is cancelled,
+// so that a cancelled chain like `a || b` stops too.
+func (in *interpreter) exec(p fullRunCommandParams, s statement) error {
+	if err := p.context().Err(); err != nil {
+		return err
+	}
+
+	switch s := s.(type) {
+	case *commandStatement:
+		return in.execCommand(p, s)
+	case *chainStatement:
+		return runChain(s.segments, func(s statement) error {
+			return in.exec(p, s)
+		})
+	case *ifStatement:
+		for _, branch := range s.branches {
+			if in.execList(p, branch.condition) == nil {
+				return in.execList(p, branch.body)
+			}
+
+			if err := p.context().Err(); err != nil {
+				return err
+			}
+		}
+
+		return in.execList(p, s.otherwise)
+	case *forStatement:
+		return in.execFor(p, s)
+	case *backgroundStatement:
+		if in.start == nil {
+			return ErrorBackgroundJobs
+		}
+
+		return in.start(p, s.stmt)
+	case *funcStatement:
+		p.session().define(s)
+	}
+
+	return nil
+}
+
+// execFor runs the body of the loop for every word. Unquoted substitutions are split at spaces,
+// so that `for id in $(users list --ids)` runs the body for every ID.
+func (in *interpreter) execFor(p fullRunCommandParams, s *forStatement) error {
+	words, err := tokenizeExpand(strings.Join(s.words, " "), sessionExpander{p}, true)
+	if err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		if err = p.context().Err(); err != nil {
+			return err
+		}
+
+		p.session().set(s.name, word)
+
+		err = in.execList(p, s.body)
+	}
+
+	return err
+}
+
+// execCommand expands the aliases of the command, and then calls the function the command names,
+// or runs the command as a pipeline.
+func (in *interpreter) execCommand(p fullRunCommandParams, s *commandStatement) error {
+	if p.app != nil {
+		if line, expanded := p.app.expandAliases(s.words, in.expanding); len(expanded) > 0 {
+			return in.execAlias(p, line, expanded)
+		}
+	}
+
+	if fn := lookupFunction(p.session(), s); fn != nil {
+		args, err := tokenizeExpand(strings.Join(s.words[1:], " "), sessionExpander{p}, false)
+		if err != nil {
+			return err
+		}
+
+		if in.depth >= maxCallDepth {
+			return newErrorCallDepth(fn.name)
+		}
+
+		in.depth++
+		defer func() { in.depth-- }()
+
+		p.args = args
+
+		return in.execList(p, fn.body)
+	}
+
+	p.command = s.String()
+
+	return in.run(p)
+}
+
+// execAlias runs the command line an alias expanded to. It can be any command line, e.g. a chain.
+func (in *interpreter) execAlias(p fullRunCommandParams, line string, expanded []string) error {
+	program, err := parseProgram(line)
+	if err != nil {
+		return err
+	}
+
+	if in.expanding == nil {
+		in.expanding = map[string]bool{}
+	}
+
+	for _, name := range expanded {
+		in.expanding[name] = true
+	}
+
+	defer func() {
+		for _, name := range expanded {
+			delete(in.expanding, name)
+		}
+	}()
+
+	return in.execList(p, program)
+}
+
+// lookupFunction returns the function the command calls, or nil. Pipelines and redirections
+// are not supported for functions, so a command that has them is not a call.
+func lookupFunction(sess *session, s *commandStatement) *funcStatement {
+	if slices.ContainsFunc(s.words, isPipelineOperator) {
+		return nil
+	}
+
+	name, err := tokenize(s.words[0])
+	if err != nil || len(name) != 1 {
+		return nil
+	}
+
+	return sess.function(name[0])
+}
@@ -0,0 +1,129 @@
+package replyme
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runProgram runs the command line with commands that fail if their text contains "fail"
+// and returns the commands that ran, expanded.
+func runProgram(ctx context.Context, app *App, input string) ([]string, error) {
+	program, err := parseProgram(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []string
+
+	in := &interpreter{run: func(p fullRunCommandParams) error {
+		words, err := tokenizeExpand(p.command, sessionExpander{p}, false)
+		if err != nil {
+			return err
+		}
+
+		command := strings.Join(words, " ")
+		ran = append(ran, command)
+
+		if strings.Contains(command, "fail") {
+			return errors.New("failed")
+		}
+
+		return nil
+	}}
+
+	return ran, in.execList(fullRunCommandParams{ctx: ctx, app: app}, program)
+}
+
+func TestInterpreter(t *testing.T) {
+	app := &App{}
+
+	tests := []struct {
+		input string
+		ran   []string
+	}{
+		{"if ping db; then migrate; fi", []string{"ping db", "migrate"}},
+		{"if ping fail; then migrate; fi; after", []string{"ping fail", "after"}},
+		{
+			"if a fail; then b; elif c; then d; else e; fi",
+			[]string{"a fail", "c", "d"},
+		},
+		{"if fail; then b; else c; fi && d", []string{"fail", "c", "d"}},
+		{
+			`for id in 1 '2 3' $ids "$ids"; do users disable $id; done`,
+			[]string{"users disable 1", "users disable 2 3", "users disable 4", "users disable 5", "users disable 4 5"},
+		},
+		{"for x in a fail b; do echo $x; done", []string{"echo a", "echo fail", "echo b"}},
+		{
+			"func deploy() { build $1 && push $1 $#; }; deploy web; deploy fail 'two words'",
+			[]string{"build web", "push web 1", "build fail"},
+		},
+		{"func greet { echo $@; }; greet a b | count", []string{"greet a b | count"}},
+	}
+
+	app.session.set("ids", "4 5")
+
+	for _, tt := range tests {
+		ran, _ := runProgram(context.Background(), app, tt.input)
+		if !reflect.DeepEqual(ran, tt.ran) {
+			t.Errorf("%s: ran %q, want %q", tt.input, ran, tt.ran)
+		}
+	}
+
+	if _, err := runProgram(context.Background(), app, "if fail; then a; fi"); err != nil {
+		t.Errorf("if with a false condition returns %v, want nil", err)
+	}
+
+	if _, err := runProgram(context.Background(), app, "for x in a b fail; do $x; done"); err == nil {
+		t.Error("for returns nil, want the error of the last iteration")
+	}
+
+	if _, err := runProgram(context.Background(), app, "func loop() { loop; }; loop"); !errors.Is(err, ErrorCallDepth) {
+		t.Errorf("got %v, want a call depth error", err)
+	}
+}
+
+func TestInterpreter_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	program, err := parseProgram("for x in a b c; do step $x; done; after")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+
+	in := &interpreter{run: func(p fullRunCommandParams) error {
+		ran = append(ran, p.command)
+		cancel()
+
+		return nil
+	}}
+
+	err = in.execList(fullRunCommandParams{ctx: ctx}, program)
+	if !errors.Is(err, context.Canceled) || len(ran) != 1 {
+		t.Errorf("got %v after %q, want the loop to stop after the first iteration", err, ran)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	ran = nil
+
+	program, err = parseProgram("a || b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in.run = func(p fullRunCommandParams) error {
+		ran = append(ran, p.command)
+		cancel()
+
+		return p.context().Err()
+	}
+
+	err = in.execList(fullRunCommandParams{ctx: ctx}, program)
+	if !errors.Is(err, context.Canceled) || len(ran) != 1 {
+		t.Errorf("got %v after %q, want the chain to stop when it is cancelled", err, ran)
+	}
+}
@@ -0,0 +1,273 @@
+package replyme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	jobsCommandName = "jobs"
+	fgCommandName   = "fg"
+	waitCommandName = "wait"
+	killCommandName = "kill"
+)
+
+// job is a command line started in the background with `&`.
+type job struct {
+	id      int
+	command string
+	cancel  context.CancelFunc
+	done    chan struct{}
+	// The result of the command line, set before done is closed
+	err error
+}
+
+// finished reports whether the job is done, and its error if it is.
+func (j *job) finished() (bool, error) {
+	select {
+	case <-j.done:
+		return true, j.err
+	default:
+		return false, nil
+	}
+}
+
+// wait waits until the job is done and returns its error, or the error of ctx if it is cancelled first.
+func (j *job) wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		return j.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jobTable is the list of the jobs of the session. The finished jobs stay in it
+// until they are reported by `jobs` or waited for with `fg` or `wait`.
+type jobTable struct {
+	mu   sync.Mutex
+	jobs []*job
+}
+
+// start adds a running job. Like in the shell, its ID is one more than the largest ID in the table.
+func (t *jobTable) start(command string, cancel context.CancelFunc) *job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := 1
+	for _, j := range t.jobs {
+		if j.id >= id {
+			id = j.id + 1
+		}
+	}
+
+	j := &job{id: id, command: command, cancel: cancel, done: make(chan struct{})}
+	t.jobs = append(t.jobs, j)
+
+	return j
+}
+
+// finish records the result of the job.
+func (j *job) finish(err error) {
+	j.err = err
+	j.cancel()
+	close(j.done)
+}
+
+func (t *jobTable) remove(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.jobs = slices.DeleteFunc(t.jobs, func(other *job) bool { return other == j })
+}
+
+// list returns the jobs ordered by ID.
+func (t *jobTable) list() []*job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.jobs)
+}
+
+// counts returns the number of running and finished jobs.
+func (t *jobTable) counts() (running, finished int) {
+	for _, j := range t.list() {
+		if done, _ := j.finished(); done {
+			finished++
+		} else {
+			running++
+		}
+	}
+
+	return running, finished
+}
+
+// lookup returns the job of a spec like `%1` or `1`. An empty spec is the last started job.
+func (t *jobTable) lookup(spec string) (*job, error) {
+	jobs := t.list()
+
+	if spec == "" {
+		if len(jobs) == 0 {
+			return nil, newErrorUnknownJob("%")
+		}
+
+		return jobs[len(jobs)-1], nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(spec, "%"))
+	if err == nil {
+		if i := slices.IndexFunc(jobs, func(j *job) bool { return j.id == id }); i != -1 {
+			return jobs[i], nil
+		}
+	}
+
+	return nil, newErrorUnknownJob(spec)
+}
+
+// lookupAll returns the jobs of the specs, or every job if there are none.
+func (t *jobTable) lookupAll(specs []string) ([]*job, error) {
+	if len(specs) == 0 {
+		return t.list(), nil
+	}
+
+	jobs := make([]*job, 0, len(specs))
+
+	for _, spec := range specs {
+		j, err := t.lookup(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// jobState returns the state of a job as `jobs` shows it.
+func jobState(done bool, err error) string {
+	switch {
+	case !done:
+		return L(i18n_job_state_running)
+	case errors.Is(err, context.Canceled):
+		return L(i18n_job_state_killed)
+	case err != nil:
+		return L(i18n_job_state_failed)
+	default:
+		return L(i18n_job_state_done)
+	}
+}
+
+// jobLine describes the job, like `[1] Running  port-forward db`.
+func jobLine(j *job, done bool, err error) string {
+	return fmt.Sprintf("[%d] %-8s %s", j.id, jobState(done, err), j.command)
+}
+
+// printJobs prints the jobs, and removes the finished ones from the table like the shell does.
+func (t *jobTable) printJobs(w io.Writer) error {
+	for _, j := range t.list() {
+		done, jobErr := j.finished()
+
+		if _, err := fmt.Fprintln(w, jobLine(j, done, jobErr)); err != nil {
+			return err
+		}
+
+		if done {
+			t.remove(j)
+		}
+	}
+
+	return nil
+}
+
+// setJobCommands adds the `jobs`, `fg`, `wait` and `kill` commands, unless the application has commands with these names.
+// The jobs are started by the REPL, so the commands are only added to it.
+func (a *App) setJobCommands() {
+	fgJob := &Argument{Name: "job", Usage: L(i18n_app_fg_job_usage), Optional: true}
+	waitJobs := &Argument{Name: "jobs", Usage: L(i18n_app_wait_jobs_usage), Optional: true, Variadic: true}
+	killJobs := &Argument{Name: "jobs", Usage: L(i18n_app_kill_jobs_usage), Variadic: true}
+
+	commands := Commands{
+		{
+			Name:  jobsCommandName,
+			Usage: L(i18n_app_jobs_usage),
+			Action: func(ctx *Context) error {
+				return a.jobs.printJobs(ctx.Stdout())
+			},
+		},
+		{
+			Name:             fgCommandName,
+			Usage:            L(i18n_app_fg_usage),
+			Arguments:        []*Argument{fgJob},
+			waitsForCommands: true,
+			Action: func(ctx *Context) error {
+				j, err := a.jobs.lookup(MustGetArg[string](ctx, fgJob.Name))
+				if err != nil {
+					return err
+				}
+
+				err = j.wait(ctx.Ctx())
+				if ctx.IsCancelled() {
+					// Ctrl+C stops the job that is brought to the foreground, like in the shell
+					j.cancel()
+				}
+
+				if done, _ := j.finished(); done {
+					a.jobs.remove(j)
+				}
+
+				return err
+			},
+		},
+		{
+			Name:             waitCommandName,
+			Usage:            L(i18n_app_wait_usage),
+			Arguments:        []*Argument{waitJobs},
+			waitsForCommands: true,
+			Action: func(ctx *Context) error {
+				jobs, err := a.jobs.lookupAll(MustGetArg[[]string](ctx, waitJobs.Name))
+				if err != nil {
+					return err
+				}
+
+				var errs []error
+
+				for _, j := range jobs {
+					if err = j.wait(ctx.Ctx()); ctx.IsCancelled() {
+						return ctx.Ctx().Err()
+					}
+
+					a.jobs.remove(j)
+					errs = append(errs, err)
+				}
+
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name:      killCommandName,
+			Usage:     L(i18n_app_kill_usage),
+			Arguments: []*Argument{killJobs},
+			Action: func(ctx *Context) error {
+				jobs, err := a.jobs.lookupAll(MustGetArg[[]string](ctx, killJobs.Name))
+				if err != nil {
+					return err
+				}
+
+				for _, j := range jobs {
+					j.cancel()
+				}
+
+				return nil
+			},
+		},
+	}
+
+	a.addBuiltinCommands(commands...)
+}
@@ -0,0 +1,170 @@
+package replyme
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJobTable(t *testing.T) {
+	var jobs jobTable
+
+	first := jobs.start("a", func() {})
+	second := jobs.start("b", func() {})
+
+	if first.id != 1 || second.id != 2 {
+		t.Fatalf("got IDs %d and %d, want 1 and 2", first.id, second.id)
+	}
+
+	second.finish(nil)
+	jobs.remove(second)
+
+	if third := jobs.start("c", func() {}); third.id != 2 {
+		t.Errorf("got ID %d, want 2", third.id)
+	}
+
+	if running, finished := jobs.counts(); running != 2 || finished != 0 {
+		t.Errorf("got %d running and %d finished jobs, want 2 and 0", running, finished)
+	}
+
+	for spec, want := range map[string]string{"%1": "a", "1": "a", "": "c"} {
+		if j, err := jobs.lookup(spec); err != nil || j.command != want {
+			t.Errorf("lookup(%q) = %v, %v, want %s", spec, j, err, want)
+		}
+	}
+
+	for _, spec := range []string{"%3", "x", "%"} {
+		if _, err := jobs.lookup(spec); !errors.Is(err, ErrorUnknownJob) {
+			t.Errorf("lookup(%q): got %v, want %v", spec, err, ErrorUnknownJob)
+		}
+	}
+}
+
+func TestJobCommands(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{}, 1)
+
+	app := newScriptApp(nil)
+	app.Commands = append(app.Commands, &Command{
+		Name: "port-forward",
+		Action: func(ctx *Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+
+			return ctx.Ctx().Err()
+		},
+	})
+	app.setJobCommands()
+
+	m := &model{app: app, modelLogs: modelLogs{logs: &logs{}, logsChan: make(chan log, 100), runningChan: make(chan runningCommandMsg, 100)}}
+
+	m.runCommandLine(context.Background(), 1, "port-forward & echo hi &")
+	<-started
+
+	m.runCommandLine(context.Background(), 1, "wait %2 && jobs")
+	m.runCommandLine(context.Background(), 1, "kill %1; fg %1")
+	m.runCommandLine(context.Background(), 1, "fg")
+	close(m.logsChan)
+
+	var messages []string
+
+	var failures []error
+
+	var cancelled []string
+
+	for l := range m.logsChan {
+		switch l.Type {
+		case logTypeMessage:
+			messages = append(messages, strings.TrimSpace(l.Message))
+		case logTypeCommandFailure:
+			failures = append(failures, l.Error)
+		case logTypeCommandCancelled:
+			cancelled = append(cancelled, l.Command)
+		}
+	}
+
+	for _, want := range []string{
+		"[2] hi",
+		"[2] Done     echo hi",
+		"[1] Running  port-forward",
+		"[1] Killed   port-forward",
+	} {
+		if !slices.Contains(messages, want) {
+			t.Errorf("the logs %q do not contain %q", messages, want)
+		}
+	}
+
+	if !slices.Equal(cancelled, []string{"[1] port-forward", "fg %1"}) {
+		t.Errorf("got cancelled commands %q, want port-forward and fg", cancelled)
+	}
+
+	if len(failures) != 1 || !errors.Is(failures[0], ErrorUnknownJob) {
+		t.Errorf("got failures %v, want fg without jobs to fail", failures)
+	}
+
+	if jobs := app.jobs.list(); len(jobs) != 0 {
+		t.Errorf("got %d jobs after fg, want none", len(jobs))
+	}
+
+	err := RunScript(newScriptApp(nil), strings.NewReader("echo a &"), ScriptParams{Stdout: &bytes.Buffer{}})
+	if !errors.Is(err, ErrorBackgroundJobs) {
+		t.Errorf("got %v, want %v", err, ErrorBackgroundJobs)
+	}
+}
+
+func TestJobCommands_OwnValues(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	results := make(chan string, 3)
+
+	app := newScriptApp(nil)
+	app.Commands = append(app.Commands, &Command{
+		Name:      "deploy",
+		Flags:     Flags{&FlagValue[string]{Name: "env"}, &FlagValue[bool]{Name: "hold"}},
+		Arguments: []*Argument{{Name: "targets", Optional: true, Variadic: true}},
+		Action: func(ctx *Context) error {
+			if ctx.GetFlagBool("hold") {
+				started <- struct{}{}
+				<-release
+			}
+
+			results <- ctx.GetFlagString("env", "") + ":" + strings.Join(MustGetArg[[]string](ctx, "targets"), ",")
+
+			return nil
+		},
+	})
+	app.setJobCommands()
+
+	m := &model{app: app, modelLogs: modelLogs{logs: &logs{}, logsChan: make(chan log, 100), runningChan: make(chan runningCommandMsg, 100)}}
+
+	m.runCommandLine(context.Background(), 1, "deploy --hold --env prod a &")
+	m.runCommandLine(context.Background(), 1, "deploy --hold --env stage b &")
+	<-started
+	<-started
+
+	m.runCommandLine(context.Background(), 1, "deploy --env dev c")
+	close(release)
+	m.runCommandLine(context.Background(), 1, "wait")
+	close(results)
+
+	var got []string
+	for result := range results {
+		got = append(got, result)
+	}
+
+	slices.Sort(got)
+
+	if want := []string{"dev:c", "prod:a", "stage:b"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want every run to keep its own flags and arguments", got)
+	}
+}
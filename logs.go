@@ -1,6 +1,7 @@
 package replyme
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/charmbracelet/glamour"
 	"slices"
@@ -8,43 +9,88 @@ import (
 	"time"
 )
 
-func (m *model) renderMarkdown(content string) string {
+func (m *model) renderMarkdown(command, content string) string {
 	renderer, err := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(m.windowHeight))
 	if err != nil {
-		m.logsChan <- log{logTypeError, m.runningCommand, fmt.Sprintf("error creating renderer: %v", err), nil, time.Now()}
+		m.logsChan <- log{logTypeError, command, fmt.Sprintf("error creating renderer: %v", err), nil, time.Now()}
 	}
 
 	render, err := renderer.Render(content)
 	if err != nil {
-		m.logsChan <- log{logTypeError, m.runningCommand, fmt.Sprintf("error render: %v", err), nil, time.Now()}
+		m.logsChan <- log{logTypeError, command, fmt.Sprintf("error render: %v", err), nil, time.Now()}
 	}
 
 	return render
 }
 
-func (m *model) emitLog(l logMsg) {
+// logger returns the emitLog of the command. Its messages start with prefix, e.g. the tag of a job.
+// The command is passed by the run, because the command that runs in the REPL is only known in Update.
+func (m *model) logger(command, prefix string) func(logMsg) {
+	return func(l logMsg) {
+		l.Content = prefix + l.Content
+		m.emitLog(command, l)
+	}
+}
+
+func (m *model) emitLog(command string, l logMsg) {
 	if l.Data == nil {
 		l.Data = []interface{}{}
 	}
 
 	switch l.Status {
 	case logMsgStatusPrint:
-		m.logsChan <- log{logTypeLog, m.runningCommand, l.Content, nil, time.Now()}
+		m.logsChan <- log{logTypeLog, command, l.Content, nil, time.Now()}
 	case logMsgStatusPrintf:
-		m.logsChan <- log{logTypeLog, m.runningCommand, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
+		m.logsChan <- log{logTypeLog, command, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
 	case logMsgStatusPrintMarkdown:
-		m.logsChan <- log{logTypeLog, m.runningCommand, m.renderMarkdown(l.Content), nil, time.Now()}
+		m.logsChan <- log{logTypeLog, command, m.renderMarkdown(command, l.Content), nil, time.Now()}
 	case logMsgStatusWarn:
-		m.logsChan <- log{logTypeWarn, m.runningCommand, l.Content, nil, time.Now()}
+		m.logsChan <- log{logTypeWarn, command, l.Content, nil, time.Now()}
 	case logMsgStatusWarnf:
-		m.logsChan <- log{logTypeWarn, m.runningCommand, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
+		m.logsChan <- log{logTypeWarn, command, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
 	case logMsgStatusError:
-		m.logsChan <- log{logTypeError, m.runningCommand, l.Content, nil, time.Now()}
+		m.logsChan <- log{logTypeError, command, l.Content, nil, time.Now()}
 	case logMsgStatusErrorf:
-		m.logsChan <- log{logTypeError, m.runningCommand, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
+		m.logsChan <- log{logTypeError, command, fmt.Sprintf(l.Content, l.Data...), nil, time.Now()}
 	}
 }
 
+// logWriter writes the output of a command to the logs, a log per line.
+type logWriter struct {
+	command string
+	// Added to the start of every line, e.g. the ID of a job
+	prefix   string
+	logType  logType
+	logsChan chan<- log
+	buf      []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		w.logsChan <- log{w.logType, w.command, w.prefix + string(w.buf[:i]), nil, time.Now()}
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the last line if it does not end with a newline.
+func (w *logWriter) Flush() error {
+	if len(w.buf) > 0 {
+		w.logsChan <- log{w.logType, w.command, w.prefix + string(w.buf), nil, time.Now()}
+		w.buf = nil
+	}
+
+	return nil
+}
+
 // LogType is the type of log.
 type logType uint16
 
@@ -54,6 +100,9 @@ const (
 	logTypeCommandFailure
 	logTypeCommandNotFound
 	logTypeCommandNotEnoughArguments
+	logTypeCommandCancelled
+	// The message is how long the command ran
+	logTypeCommandTimeout
 
 	logTypeMessage
 	logTypePanic
@@ -61,6 +110,7 @@ const (
 	logTypeDebug
 	logTypeWarn
 	logTypeError
+	logTypeSuggestion
 )
 
 type log struct {
@@ -83,6 +133,15 @@ func renderFailure(s string) string {
 	return fmt.Sprintf("%s %s %s", redIcon.Render("✖"), styles.GrayStyle(">>"), s)
 }
 
+func renderCancelled(s string) string {
+	return fmt.Sprintf("%s %s %s", yellowIcon.Render("⊘"), styles.GrayStyle(">>"), s)
+}
+
+func renderTimeout(s, elapsed string) string {
+	return fmt.Sprintf("%s %s %s %s", yellowIcon.Render("⌛"), styles.GrayStyle(">>"), s,
+		styles.GrayStyle(fmt.Sprintf(L(i18n_cmd_timeout), elapsed)))
+}
+
 func renderPanic(s string) string {
 	return fmt.Sprintf("%s: %s", styles.ErrorHeaderStyle("[PANIC]"), styles.ErrorTextStyle(s))
 }
@@ -103,6 +162,10 @@ func renderError(s string) string {
 	return fmt.Sprintf("%s: %s", styles.ErrorHeaderStyle("[ERROR]"), styles.ErrorTextStyle(s))
 }
 
+func renderSuggestion(s string) string {
+	return fmt.Sprintf("  %s", styles.GrayStyle(s))
+}
+
 func renderCommandError(cmd string, s string) string {
 	return fmt.Sprintf("%s %s %s %s", redIcon.Render("✖"), styles.GrayStyle(">>"), cmd, styles.GrayStyle("("+s+")"))
 }
@@ -118,6 +181,10 @@ func (l log) Render() string {
 		return renderSuccess(l.Command)
 	case logTypeCommandFailure:
 		return renderFailure(l.Command)
+	case logTypeCommandCancelled:
+		return renderCancelled(l.Command)
+	case logTypeCommandTimeout:
+		return renderTimeout(l.Command, l.Message)
 	case logTypeCommandNotFound, logTypeCommandNotEnoughArguments:
 		return renderCommandError(l.Command, l.Message)
 	case logTypeMessage:
@@ -132,6 +199,8 @@ func (l log) Render() string {
 		return renderError(l.Message)
 	case logTypePanic:
 		return renderPanic(l.Message)
+	case logTypeSuggestion:
+		return renderSuggestion(l.Message)
 	default:
 		return l.Message
 	}
@@ -195,8 +264,8 @@ func (l *logs) Add(t logType, message string) {
 
 // AddLog - adds a log.
 func (l *logs) AddLog(lg log) {
-	if lg.Type == logTypeCommandSuccess || lg.Type == logTypeCommandFailure ||
-		lg.Type == logTypeCommandNotFound || lg.Type == logTypeCommandNotEnoughArguments {
+	if lg.Type == logTypeCommandSuccess || lg.Type == logTypeCommandFailure || lg.Type == logTypeCommandCancelled ||
+		lg.Type == logTypeCommandTimeout || lg.Type == logTypeCommandNotFound || lg.Type == logTypeCommandNotEnoughArguments {
 		i := slices.IndexFunc(*l, func(l log) bool {
 			return lg.Command == l.Command && l.Type == logTypeCommandRunning
 		})
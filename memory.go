@@ -1,3 +1,83 @@
 package replyme
 
-var memory = make(map[string]interface{})
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// session is the state an App keeps between command lines: the memory of Context.Set,
+// which is also the session variables, and the functions defined with `func`.
+type session struct {
+	mu        sync.RWMutex
+	memory    map[string]interface{}
+	functions map[string]*funcStatement
+}
+
+func (s *session) get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.memory[key]
+
+	return value, ok
+}
+
+func (s *session) set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.memory == nil {
+		s.memory = make(map[string]interface{})
+	}
+
+	s.memory[key] = value
+}
+
+func (s *session) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.memory, key)
+}
+
+// function returns the function defined with this name, or nil.
+func (s *session) function(name string) *funcStatement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.functions[name]
+}
+
+func (s *session) define(fn *funcStatement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.functions == nil {
+		s.functions = make(map[string]*funcStatement)
+	}
+
+	s.functions[fn.name] = fn
+}
+
+// printVariables prints the session variables sorted by name.
+func (s *session) printVariables(w io.Writer) error {
+	s.mu.RLock()
+	lines := make([]string, 0, len(s.memory))
+
+	for name, value := range s.memory {
+		lines = append(lines, fmt.Sprintf("%s=%v", name, value))
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(lines)
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package replyme
 
 import (
-	"bytes"
+	"context"
+	"time"
+
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -33,12 +35,19 @@ type modelLogs struct {
 	logs                *logs
 	history             []string
 	selectedHistoryItem int
-	stdout              *bytes.Buffer
-	stderr              *bytes.Buffer
 	runningCommand      string
 	logsDirty           bool
 
+	// Cancels the running command line, nil if none is running
+	cancelLine context.CancelFunc
+	// When Ctrl+C was pressed to cancel the running command line
+	cancelledAt time.Time
+	// The number of the last command line. An abandoned line that ends later does not reset the state of the next one
+	lineID int
+
 	logsChan chan log
+	// The commands of the running command line report that they start, see runningCommandMsg
+	runningChan chan runningCommandMsg
 }
 
 type modelTUI struct {
@@ -95,7 +104,7 @@ func createModel(app *App) *model {
 		},
 		modelElements: modelElements{
 			logsViewport: createViewport(),
-			input:        newTerminalInput(),
+			input:        newTerminalInput(app.complete),
 			spinner:      createSpinner(),
 		},
 		modelLogs: modelLogs{
@@ -103,8 +112,7 @@ func createModel(app *App) *model {
 			history:             make([]string, 0),
 			selectedHistoryItem: -1,
 			logsChan:            make(chan log),
-			stdout:              bytes.NewBuffer(nil),
-			stderr:              bytes.NewBuffer(nil),
+			runningChan:         make(chan runningCommandMsg),
 		},
 	}
 
@@ -16,25 +16,128 @@ const (
 	FlagTypeIntArray
 	FlagTypeStringArray
 	FlagTypeBool
+	FlagTypeFloat
+	FlagTypeFloatArray
+	FlagTypeDuration
+	FlagTypeTime
+	FlagTypeURL
+	FlagTypeIP
+	FlagTypeCounter
+	// FlagTypeCustom is a flag of a type that is not registered with RegisterFlagType, parsed by its Parser.
+	// Registered custom types get their own FlagType values after it.
+	FlagTypeCustom
 )
 
 type flagSchema map[string]map[string]FlagType
 
-type argsSchema map[string][]*Argument
+// globalSchemaKey is the key of the flag schema that holds the global flags of the application.
+// It is the empty path, which is not the path of any command.
+const globalSchemaKey = ""
+
+// commandPathSeparator separates the names in the path that identifies a command, e.g. `users/list`.
+const commandPathSeparator = "/"
+
+// commandPath returns the path that identifies the command with the specified command tree.
+// The flag and argument schemas and the flags of the AST are keyed by it,
+// so that subcommands with the same name under different parents do not collide.
+func commandPath(tree []string) string {
+	return strings.Join(tree, commandPathSeparator)
+}
+
+// lookup returns the type of the flag of the command, falling back to the global flags.
+func (s flagSchema) lookup(command, name string) (FlagType, bool) {
+	if flagType, ok := s[command][name]; ok {
+		return flagType, true
+	}
+
+	flagType, ok := s[globalSchemaKey][name]
+
+	return flagType, ok
+}
+
+type argsSchema map[string]Arguments
 
 type commandsSchema []commandSchema
 
 type commandSchema struct {
-	Name        string
+	Name    string
+	Aliases []string
+	// Unknown flags and surplus arguments are passed through as positional arguments
+	PassThrough bool
 	Subcommands []commandSchema
 }
 
+// parseOptions are the settings of the application that affect how commands are resolved.
+type parseOptions struct {
+	// A unique prefix of a name or alias resolves to the command
+	AllowPrefixMatch bool
+	// Names and aliases are compared case-insensitively
+	CaseInsensitive bool
+	// Flags that are not declared are accepted instead of being rejected
+	AllowUnknownFlags bool
+	// Expands variables and command substitutions, nil in the CLI where the shell does it
+	Expander expander
+}
+
+// equal compares a command name or alias with the word typed by the user.
+func (o parseOptions) equal(name, word string) bool {
+	if o.CaseInsensitive {
+		return strings.EqualFold(name, word)
+	}
+
+	return name == word
+}
+
+func (o parseOptions) hasPrefix(name, word string) bool {
+	if o.CaseInsensitive {
+		return strings.HasPrefix(strings.ToLower(name), strings.ToLower(word))
+	}
+
+	return strings.HasPrefix(name, word)
+}
+
+// matchCommand returns the index of the command that the word refers to, or -1 if there is none.
+// A name or alias that matches exactly wins, otherwise a unique prefix is accepted if the options allow it.
+func matchCommand(commands commandsSchema, word string, opts parseOptions) (int, error) {
+	for i := range commands {
+		for _, name := range append([]string{commands[i].Name}, commands[i].Aliases...) {
+			if opts.equal(name, word) {
+				return i, nil
+			}
+		}
+	}
+
+	if !opts.AllowPrefixMatch || word == "" {
+		return -1, nil
+	}
+
+	match := -1
+
+	var matches []string
+
+	for i := range commands {
+		if slices.ContainsFunc(append([]string{commands[i].Name}, commands[i].Aliases...), func(name string) bool {
+			return opts.hasPrefix(name, word)
+		}) {
+			match = i
+			matches = append(matches, commands[i].Name)
+		}
+	}
+
+	if len(matches) > 1 {
+		return -1, newErrorAmbiguousPrefix(word, matches)
+	}
+
+	return match, nil
+}
+
 //nolint:gocognit,cyclop,funlen
 func parseCommand(
 	commands commandsSchema,
 	schema flagSchema,
 	argsSchema argsSchema,
 	input string,
+	opts parseOptions,
 ) (*ASTNode, error) {
 	ast := &ASTNode{
 		Flags:       map[string]map[string][]ASTFlag{},
@@ -42,7 +145,7 @@ func parseCommand(
 		Subcommands: []string{},
 	}
 
-	tokens, err := tokenize(input)
+	tokens, err := tokenizeExpand(input, opts.Expander, false)
 	if err != nil {
 		return nil, err
 	}
@@ -62,18 +165,19 @@ func parseCommand(
 	}
 
 	first := tokens[0]
-	for i := range commands {
-		if commands[i].Name == first {
-			currentCmdSchema = &commands[i]
 
-			break
-		}
+	i, err := matchCommand(commands, first, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if currentCmdSchema == nil {
-		return nil, fmt.Errorf("%w: %s", ErrorUnknownCommand, first)
+	if i == -1 {
+		return nil, &UnknownCommandError{Name: first, Suggestions: suggest(first, commandNames(commands))}
 	}
 
+	currentCmdSchema = &commands[i]
+	first = currentCmdSchema.Name
+
 	ast.Command = first
 	ast.CommandTree = append(ast.CommandTree, first)
 	lastCmd = first
@@ -97,39 +201,41 @@ func parseCommand(
 			continue
 		}
 
-		if strings.HasPrefix(token, "-") { //nolint:nestif
-			var name, value string
+		if strings.HasPrefix(token, "-") && token != "-" {
+			var next *string
+			if i+1 < len(tokens) {
+				next = &tokens[i+1]
+			}
 
-			if strings.Contains(token, "=") {
-				parts := strings.SplitN(token, "=", 2) //nolint:mnd
-				name = strings.TrimLeft(parts[0], "-")
-				value = parts[1]
-			} else {
-				name = strings.TrimLeft(token, "-")
-				flagType := schema[lastCmd][name]
+			occurrences, consumed := splitFlagToken(schema, lastCmd, token, next)
 
-				if flagType == 0 {
-					flagType = schema["global"][name]
-				}
+			if unknown := unknownFlag(schema, lastCmd, occurrences); unknown != "" {
+				switch {
+				case currentCmdSchema.PassThrough || isNumeric(token):
+					posArgs = append(posArgs, token)
 
-				if flagType == FlagTypeBool || i+1 >= len(tokens) || strings.HasPrefix(tokens[i+1], "-") {
-					value = "true"
-				} else {
-					value = tokens[i+1]
-					skip = i + 1
+					continue
+				case !opts.AllowUnknownFlags:
+					return nil, &UnknownFlagError{
+						Command:     lastCmd,
+						Flag:        unknown,
+						Suggestions: suggest(flagDisplayName(unknown), flagNames(schema, lastCmd)),
+					}
 				}
 			}
 
+			if consumed {
+				skip = i + 1
+			}
+
 			if ast.Flags[lastCmd] == nil {
 				ast.Flags[lastCmd] = map[string][]ASTFlag{}
 			}
 
-			flagType := schema[lastCmd][name]
-			if flagType == 0 {
-				flagType = schema["global"][name]
+			for _, o := range occurrences {
+				flagType, _ := schema.lookup(lastCmd, o.name)
+				ast.Flags[lastCmd][o.name] = append(ast.Flags[lastCmd][o.name], ASTFlag{Type: flagType, Value: o.value})
 			}
-
-			ast.Flags[lastCmd][name] = append(ast.Flags[lastCmd][name], ASTFlag{Type: flagType, Value: value})
 		} else {
 			if len(currentCmdSchema.Subcommands) == 0 {
 				posArgs = append(posArgs, token)
@@ -137,46 +243,170 @@ func parseCommand(
 				continue
 			}
 
-			found := false
-
-			for j := range currentCmdSchema.Subcommands {
-				if currentCmdSchema.Subcommands[j].Name == token {
-					currentCmdSchema = &currentCmdSchema.Subcommands[j]
-					found = true
+			j, err := matchCommand(currentCmdSchema.Subcommands, token, opts)
+			if err != nil {
+				return nil, err
+			}
 
-					break
+			if j == -1 {
+				return nil, &UnknownSubcommandError{
+					Command:     lastCmd,
+					Name:        token,
+					Suggestions: suggest(token, commandNames(currentCmdSchema.Subcommands)),
 				}
 			}
 
-			if !found {
-				return nil, newErrorSubcommandUnknown(token)
-			}
+			currentCmdSchema = &currentCmdSchema.Subcommands[j]
+			token = currentCmdSchema.Name
 
-			lastCmd = token
 			ast.CommandTree = append(ast.CommandTree, token)
 			ast.Subcommands = append(ast.Subcommands, token)
+			lastCmd = commandPath(ast.CommandTree)
 		}
 	}
 
 	expected := argsSchema[lastCmd]
-	if len(posArgs) < len(expected) {
-		return nil, newErrorArgumentNotFound(lastCmd)
+	if len(posArgs) < requiredArguments(expected) {
+		return nil, newErrorArgumentNotFound(currentCmdSchema.Name)
 	}
 
-	for i, def := range expected {
-		if i < len(posArgs) {
-			ast.Arguments = append(ast.Arguments, ASTArgument{Name: def.Name, Value: posArgs[i]})
-		}
+	arguments, surplus := assignArguments(expected, posArgs)
+	if len(surplus) > 0 && !currentCmdSchema.PassThrough {
+		return nil, &TooManyArgumentsError{Command: lastCmd, Max: len(posArgs) - len(surplus), Args: surplus}
 	}
 
+	ast.Arguments = arguments
 	ast.Args = posArgs
 	ast.FullCommand = input
+	ast.CommandPath = lastCmd
 
 	return ast, nil
 }
 
+// flagOccurrence - a flag with its value, as it was given on the command line.
+type flagOccurrence struct {
+	name  string
+	value string
+}
+
+// splitFlagToken turns a token starting with "-" into flag occurrences. It resolves `--name=value`,
+// `--name value`, `--no-name` for bool flags, bundled short flags `-xvf` and attached short values `-ovalue`.
+// next is the token after it, if any, and consumed reports whether it was taken as the value.
+func splitFlagToken(schema flagSchema, command, token string, next *string) ([]flagOccurrence, bool) {
+	long := strings.HasPrefix(token, "--")
+	name, value, hasValue := strings.Cut(strings.TrimLeft(token, "-"), "=")
+
+	if _, ok := schema.lookup(command, name); !ok {
+		if base, ok := strings.CutPrefix(name, "no-"); long && ok && !hasValue {
+			if flagType, ok := schema.lookup(command, base); ok && flagType.Kind() == FlagKindBool {
+				return []flagOccurrence{{name: base, value: "false"}}, false
+			}
+		}
+
+		if !long && len(name) > 1 {
+			if _, ok := schema.lookup(command, name[:1]); ok {
+				return splitShortFlags(schema, command, token[1:], next)
+			}
+		}
+	}
+
+	if hasValue {
+		return []flagOccurrence{{name: name, value: value}}, false
+	}
+
+	// An unknown flag does not take the next token, so that it stays a positional argument
+	flagType, ok := schema.lookup(command, name)
+	if !ok {
+		return []flagOccurrence{{name: name, value: "true"}}, false
+	}
+
+	value, consumed := flagValueFromNext(flagType, next)
+
+	return []flagOccurrence{{name: name, value: value}}, consumed
+}
+
+// unknownFlag returns the name of the first occurrence that is not a flag of the command, or "" if all are known.
+func unknownFlag(schema flagSchema, command string, occurrences []flagOccurrence) string {
+	for _, o := range occurrences {
+		if _, ok := schema.lookup(command, o.name); !ok {
+			return o.name
+		}
+	}
+
+	return ""
+}
+
+// flagNames returns the flags that can be given to the command, as they are written on the command line.
+func flagNames(schema flagSchema, command string) []string {
+	names := make([]string, 0, len(schema[command])+len(schema[globalSchemaKey]))
+
+	for _, flags := range []map[string]FlagType{schema[command], schema[globalSchemaKey]} {
+		for name := range flags {
+			names = append(names, flagDisplayName(name))
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// flagDisplayName returns the flag as it is written on the command line: `-v` for short names, `--name` otherwise.
+func flagDisplayName(name string) string {
+	if len([]rune(name)) == 1 {
+		return "-" + name
+	}
+
+	return "--" + name
+}
+
+// splitShortFlags resolves a bundle of short flags like `-xvf file` against the aliases in the schema.
+// The first flag that takes a value gets the rest of the bundle (`-ovalue`, `-o=value`) or the next token.
+func splitShortFlags(schema flagSchema, command, letters string, next *string) ([]flagOccurrence, bool) {
+	var occurrences []flagOccurrence
+
+	for i, r := range letters {
+		name := string(r)
+		rest := letters[i+len(name):]
+		flagType, _ := schema.lookup(command, name)
+
+		if flagType.Kind().takesValue() || strings.HasPrefix(rest, "=") {
+			if rest != "" {
+				return append(occurrences, flagOccurrence{name: name, value: strings.TrimPrefix(rest, "=")}), false
+			}
+
+			value, consumed := flagValueFromNext(flagType, next)
+
+			return append(occurrences, flagOccurrence{name: name, value: value}), consumed
+		}
+
+		occurrences = append(occurrences, flagOccurrence{name: name, value: "true"})
+	}
+
+	return occurrences, false
+}
+
+// flagValueFromNext returns the value of a flag given without "=": the next token if the flag takes a value,
+// otherwise "true".
+func flagValueFromNext(flagType FlagType, next *string) (string, bool) {
+	if !flagType.Kind().takesValue() || next == nil || strings.HasPrefix(*next, "-") {
+		return "true", false
+	}
+
+	return *next, true
+}
+
 //nolint:cyclop
 func tokenize(input string) ([]string, error) {
+	return tokenizeExpand(input, nil, false)
+}
+
+// tokenizeExpand splits the command line into words. If e is set, `$name`, `${name}` and `$(command)`
+// are expanded, except inside single quotes or after a backslash. The values are split into words
+// at spaces only if split is set and they are not quoted, as in the word list of `for`.
+//
+//nolint:cyclop,funlen
+func tokenizeExpand(input string, e expander, split bool) ([]string, error) {
 	var result []string
 
 	var current strings.Builder
@@ -187,7 +417,11 @@ func tokenize(input string) ([]string, error) {
 
 	var escape bool
 
-	for _, r := range input {
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
 		switch {
 		case escape:
 			current.WriteRune(r)
@@ -197,6 +431,29 @@ func tokenize(input string) ([]string, error) {
 		case r == '\\':
 			escape = true
 
+		case r == '$' && e != nil && (!inQuote || quoteChar == '"'):
+			value, n, err := expandAt(runes[i:], e)
+			if err != nil {
+				return nil, err
+			}
+
+			i += n - 1
+
+			if !split || inQuote {
+				current.WriteString(value)
+
+				continue
+			}
+
+			for _, v := range value {
+				if !unicode.IsSpace(v) {
+					current.WriteRune(v)
+				} else if current.Len() > 0 {
+					result = append(result, current.String())
+					current.Reset()
+				}
+			}
+
 		case r == '"' || r == '\'':
 			if inQuote {
 				if r == quoteChar {
@@ -237,59 +494,62 @@ func tokenize(input string) ([]string, error) {
 	return result, nil
 }
 
-//nolint:cyclop
 func createFlagSchema(commands Commands) flagSchema {
 	schema := make(flagSchema)
+	addFlagSchema(schema, commands, nil, nil)
 
+	return schema
+}
+
+// addFlagSchema adds the flags of the commands to the schema, including the persistent flags
+// inherited from their parents. parent is the command tree of the parent command.
+func addFlagSchema(schema flagSchema, commands Commands, parent []string, inherited Flags) {
 	for _, command := range commands {
-		for _, flag := range command.Flags {
-			if schema[command.Name] == nil {
-				schema[command.Name] = make(map[string]FlagType)
-			}
+		tree := append(slices.Clone(parent), command.Name)
+		path := commandPath(tree)
+		persistent := append(slices.Clone(inherited), command.PersistentFlags...)
 
-			switch flag.ValueType() {
-			case "string":
-				schema[command.Name][flag.GetName()] = FlagTypeString
-			case "int":
-				schema[command.Name][flag.GetName()] = FlagTypeInt
-			case "[]string":
-				schema[command.Name][flag.GetName()] = FlagTypeStringArray
-			case "[]int":
-				schema[command.Name][flag.GetName()] = FlagTypeIntArray
-			case "bool":
-				schema[command.Name][flag.GetName()] = FlagTypeBool
+		for _, flag := range append(slices.Clone(persistent), command.Flags...) {
+			if schema[path] == nil {
+				schema[path] = make(map[string]FlagType)
 			}
+
+			addFlagType(schema[path], flag)
 		}
 
 		if command.Subcommands != nil && len(command.Subcommands) > 0 {
-			newSchema := createFlagSchema(command.Subcommands)
-			for k, v := range newSchema {
-				schema[k] = v
-			}
+			addFlagSchema(schema, command.Subcommands, tree, persistent)
 		}
 	}
+}
 
-	return schema
+func addFlagType(schema map[string]FlagType, flag FlagDefinition) {
+	schema[flag.GetName()] = typeOfFlag(flag)
+	if flag.GetAlias() != "" {
+		schema[flag.GetAlias()] = typeOfFlag(flag)
+	}
 }
 
 func createArgsSchema(commands Commands) argsSchema {
 	schema := make(argsSchema)
+	addArgsSchema(schema, commands, nil)
+
+	return schema
+}
+
+func addArgsSchema(schema argsSchema, commands Commands, parent []string) {
 	for _, command := range commands {
-		schema[command.Name] = command.Arguments
+		tree := append(slices.Clone(parent), command.Name)
+		schema[commandPath(tree)] = command.arguments()
 
 		if command.Subcommands != nil && len(command.Subcommands) > 0 {
-			newSchema := createArgsSchema(command.Subcommands)
-			for k, v := range newSchema {
-				schema[k] = v
-			}
+			addArgsSchema(schema, command.Subcommands, tree)
 		}
 	}
-
-	return schema
 }
 
 func createCommandSchema(commands Commands) commandsSchema {
-	schema := make(commandsSchema, len(commands))
+	schema := make(commandsSchema, 0, len(commands))
 	if commands == nil || len(commands) == 0 {
 		return schema
 	}
@@ -297,6 +557,8 @@ func createCommandSchema(commands Commands) commandsSchema {
 	for _, command := range commands {
 		schema = append(schema, commandSchema{
 			Name:        command.Name,
+			Aliases:     command.Aliases,
+			PassThrough: command.PassThroughArgs,
 			Subcommands: createCommandSchema(command.Subcommands),
 		})
 	}
@@ -304,38 +566,165 @@ func createCommandSchema(commands Commands) commandsSchema {
 	return schema
 }
 
+// insertDataInCommand parses the flags and arguments of the command at the specified depth of the command tree.
+//
 //nolint:cyclop
-func insertDataInCommand(cmd *Command, ast *ASTNode, subcommand bool) error {
-	if flags, ok := ast.Flags[cmd.Name]; ok { //nolint:nestif
+func insertDataInCommand(app *App, cmd *Command, ast *ASTNode, depth int) error {
+	subcommand := depth != len(ast.CommandTree)-1
+
+	if flags, ok := ast.Flags[commandPath(ast.CommandTree[:depth+1])]; ok {
 		for _, cmdFlag := range cmd.Flags {
-			if flag, ok := flags[cmdFlag.GetName()]; ok {
-				_, err := cmdFlag.Parse(flag[0].Value)
-				if err != nil {
-					return err
-				}
+			err := parseFlagOccurrences(app, cmd.Name, cmdFlag, flagOccurrences(cmdFlag, flags))
+			if err != nil {
+				return err
 			}
+		}
+	}
+
+	err := resolveFlags(cmd.Flags, app.EnvPrefix, app.config.section(ast.CommandTree[:depth+1]))
+	if err != nil {
+		return err
+	}
 
-			if flag, ok := flags[cmdFlag.GetAlias()]; ok {
-				_, err := cmdFlag.Parse(flag[0].Value)
+	if !subcommand {
+		for _, argument := range cmd.arguments() {
+			for _, a := range ast.Arguments {
+				if a.Name != argument.GetName() {
+					continue
+				}
+
+				err = argument.Parse(a.Value)
 				if err != nil {
 					return err
 				}
 			}
+
+			argument.ApplyDefault()
 		}
 	}
 
-	if !subcommand {
-		if cmd.Arguments != nil && len(cmd.Arguments) > 0 {
-			for _, argument := range cmd.Arguments {
-				i := slices.IndexFunc(ast.Arguments, func(a ASTArgument) bool {
-					return a.Name == argument.Name
-				})
-				if i == -1 {
-					return newErrorArgumentNotFound(argument.Name)
-				}
+	return nil
+}
+
+// insertInheritedFlags parses the persistent flags of the command at the specified depth of the flow,
+// or the global flags if owner is nil. They can be given after the owner or any of its subcommands
+// that does not declare a flag with the same name itself.
+func insertInheritedFlags(app *App, owner *Command, flags Flags, ast *ASTNode, flow []*Command, depth int) error {
+	name := app.Name
+	if owner != nil {
+		name = owner.Name
+	}
 
-				cmd.Arguments[i].value = ast.Arguments[i].Value
+	for _, flag := range flags {
+		var occurrences []ASTFlag
+
+		for i := depth; i < len(flow); i++ {
+			if flow[i] != owner && flow[i].declaresFlag(flag) {
+				continue
 			}
+
+			occurrences = append(occurrences, flagOccurrences(flag, ast.Flags[commandPath(ast.CommandTree[:i+1])])...)
+		}
+
+		err := parseFlagOccurrences(app, name, flag, occurrences)
+		if err != nil {
+			return err
+		}
+	}
+
+	var section configSection
+	if owner != nil {
+		section = app.config.section(ast.CommandTree[:depth+1])
+	} else {
+		section = app.config.section(nil)
+	}
+
+	return resolveFlags(flags, app.EnvPrefix, section)
+}
+
+// flagOccurrences returns the occurrences of the flag, given by its name or alias.
+func flagOccurrences(flag FlagDefinition, flags map[string][]ASTFlag) []ASTFlag {
+	occurrences := slices.Clone(flags[flag.GetName()])
+	if flag.GetAlias() != "" && flag.GetAlias() != flag.GetName() {
+		occurrences = append(occurrences, flags[flag.GetAlias()]...)
+	}
+
+	return occurrences
+}
+
+// parseFlagOccurrences parses every occurrence of the flag.
+// List flags accumulate the values and counters count the occurrences,
+// other flags can be given only once unless the application allows repeated flags.
+func parseFlagOccurrences(app *App, command string, flag FlagDefinition, occurrences []ASTFlag) error {
+	if len(occurrences) > 1 && !kindOfFlag(flag).repeatable() && !app.AllowRepeatedFlags {
+		return &RepeatedFlagError{Command: command, Flag: flag.GetName()}
+	}
+
+	for _, occurrence := range occurrences {
+		_, err := flag.Parse(occurrence.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveFlags fills the flags that were not set on the command line,
+// first from the environment, then from the config files and then from their default values.
+func resolveFlags(flags Flags, envPrefix string, section configSection) error {
+	for _, flag := range flags {
+		if _, err := flag.ParsedValue(); err == nil {
+			continue
+		}
+
+		if value, source, ok := lookupFlagSource(flag, envPrefix, section); ok {
+			if _, err := flag.Parse(value); err != nil {
+				return fmt.Errorf("%s: %w", source.name, err)
+			}
+		}
+
+		applyFlagDefault(flag)
+	}
+
+	return nil
+}
+
+// flagEnvVars returns the environment variables of the flag. If the application has an EnvPrefix,
+// the flag is also bound to PREFIX_FLAG_NAME.
+func flagEnvVars(flag FlagDefinition, envPrefix string) []string {
+	var envs []string
+	if f, ok := flag.(FlagEnvReader); ok {
+		envs = f.GetEnvVars()
+	}
+
+	// The help and timeout flags are added by replyme itself, so they are never bound automatically
+	if _, ok := flag.(timeoutFlag); ok || envPrefix == "" || flag.GetName() == "help" {
+		return envs
+	}
+
+	name := strings.ToUpper(strings.ReplaceAll(flag.GetName(), "-", "_"))
+
+	return append(slices.Clone(envs), strings.TrimSuffix(envPrefix, "_")+"_"+name)
+}
+
+func checkRequiredFlags(flow []*Command) error {
+	for _, cmd := range flow {
+		for _, flags := range []Flags{cmd.Flags, cmd.PersistentFlags} {
+			err := checkRequired(cmd.Name, flags)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkRequired(command string, flags Flags) error {
+	for _, flag := range flags {
+		if _, err := flag.ParsedValue(); err != nil && flagRequired(flag) {
+			return &RequiredFlagError{Command: command, Flag: flag.GetName()}
 		}
 	}
 
@@ -432,6 +821,13 @@ func isQuoted(s string) bool {
 		(strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'"))
 }
 
+// isNumeric reports whether the token is a number like `-5` or `-0.5`, rather than a flag.
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+
+	return err == nil
+}
+
 func isNumber(s string) bool {
 	_, err := strconv.Atoi(s)
 
@@ -3,7 +3,9 @@ package replyme
 import (
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseCommand_BasicCommand(t *testing.T) {
@@ -12,7 +14,7 @@ func TestParseCommand_BasicCommand(t *testing.T) {
 	schema := flagSchema{}
 	argsSchema := argsSchema{}
 
-	ast, err := parseCommand(commands, schema, argsSchema, input)
+	ast, err := parseCommand(commands, schema, argsSchema, input, parseOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -34,11 +36,11 @@ func TestParseCommand_WithFlagAndArgs(t *testing.T) {
 	}
 	argsSchema := argsSchema{
 		"build": {
-			{Name: "input"},
+			&Argument{Name: "input"},
 		},
 	}
 
-	ast, err := parseCommand(commands, schema, argsSchema, input)
+	ast, err := parseCommand(commands, schema, argsSchema, input, parseOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -62,12 +64,12 @@ func TestParseCommand_WithSubcommands(t *testing.T) {
 	}}
 	schema := flagSchema{}
 	argsSchema := argsSchema{
-		"insert": {
-			{Name: "file"},
+		"db/insert": {
+			&Argument{Name: "file"},
 		},
 	}
 
-	ast, err := parseCommand(commands, schema, argsSchema, input)
+	ast, err := parseCommand(commands, schema, argsSchema, input, parseOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -87,6 +89,7 @@ func TestParseCommand_UnknownCommand(t *testing.T) {
 		flagSchema{},
 		argsSchema{},
 		"unknowncmd",
+		parseOptions{},
 	)
 	if err == nil || err.Error() != "unknown subcommand: unknowncmd" && !errors.Is(err, ErrorUnknownCommand) {
 		t.Errorf("expected unknown command error, got %v", err)
@@ -106,3 +109,406 @@ func TestTokenize(t *testing.T) {
 		t.Errorf("expected tokens %v, got %v", expected, tokens)
 	}
 }
+
+func TestResolveFlags(t *testing.T) {
+	t.Setenv("TEST_REGION", "eu")
+	t.Setenv("MYAPP_COUNT", "3")
+
+	region := &FlagValue[string]{Name: "region", EnvVars: []string{"TEST_REGION"}, Default: "us"}
+	count := &FlagValue[int]{Name: "count", Default: 1}
+	name := &FlagValue[string]{Name: "name", Default: "anonymous"}
+	cli := &FlagValue[string]{Name: "cli", EnvVars: []string{"TEST_REGION"}}
+
+	_, err := cli.Parse("from-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = resolveFlags(Flags{region, count, name, cli}, "MYAPP_", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := Flags{region, count, name, cli}
+
+	switch {
+	case flags.GetFlagString("region", "") != "eu":
+		t.Errorf("region: got %q, want the environment value", flags.GetFlagString("region", ""))
+	case flags.GetFlagInt("count", 0) != 3:
+		t.Errorf("count: got %d, want the prefixed environment value", flags.GetFlagInt("count", 0))
+	case flags.GetFlagString("name", "") != "anonymous":
+		t.Errorf("name: got %q, want the default value", flags.GetFlagString("name", ""))
+	case flags.GetFlagString("cli", "") != "from-cli":
+		t.Errorf("cli: got %q, the command line must take precedence", flags.GetFlagString("cli", ""))
+	}
+
+	t.Setenv("MYAPP_COUNT", "three")
+
+	err = resolveFlags(Flags{&FlagValue[int]{Name: "count"}}, "MYAPP", nil)
+	if err == nil || !strings.Contains(err.Error(), "MYAPP_COUNT") {
+		t.Errorf("expected a parse error mentioning the variable, got %v", err)
+	}
+}
+
+func TestCheckRequiredFlags(t *testing.T) {
+	cmd := &Command{
+		Name: "deploy",
+		Flags: Flags{
+			&FlagValue[string]{Name: "env", Required: true},
+		},
+	}
+
+	err := checkRequiredFlags([]*Command{cmd})
+
+	var required *RequiredFlagError
+	if !errors.As(err, &required) || required.Flag != "env" || required.Command != "deploy" {
+		t.Fatalf("expected a required flag error, got %v", err)
+	}
+
+	if !errors.Is(err, ErrorRequiredFlag) {
+		t.Fatalf("expected ErrorRequiredFlag, got %v", err)
+	}
+
+	_, err = cmd.Flags[0].Parse("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkRequiredFlags([]*Command{cmd}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateCommandFlow_RepeatedFlags(t *testing.T) {
+	newApp := func() *App {
+		return &App{Commands: Commands{{
+			Name: "deploy",
+			Flags: Flags{
+				&FlagValue[[]string]{Name: "tag", Alias: "t"},
+				&FlagValue[Counter]{Name: "verbose", Alias: "v"},
+				&FlagValue[string]{Name: "env"},
+				&FlagValue[[]time.Duration]{
+					Name: "wait",
+					Parser: func(s string) ([]time.Duration, error) {
+						d, err := time.ParseDuration(s)
+
+						return []time.Duration{d}, err
+					},
+				},
+			},
+		}}}
+	}
+
+	run := func(app *App, input string) (*Command, error) {
+		ast, err := app.parse(input)
+		if err != nil {
+			return nil, err
+		}
+
+		flow, _, _, err := createCommandFlow(app, ast)
+		if err != nil {
+			return nil, err
+		}
+
+		return flow[0], nil
+	}
+
+	cmd, err := run(newApp(), "deploy --tag a --tag b,c -t d -vvv --wait 1s --wait 2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cmd.Flags.GetFlagStringArray("tag"); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("got tags %v", got)
+	}
+
+	if got := cmd.Flags.GetFlagCounter("verbose"); got != 3 {
+		t.Errorf("got verbosity %d, want 3", got)
+	}
+
+	if got, _ := lookupFlag[[]time.Duration](cmd.Flags, "wait"); !reflect.DeepEqual(got, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("got waits %v", got)
+	}
+
+	cmd, err = run(newApp(), "deploy -v --verbose -v")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cmd.Flags.GetFlagCounter("verbose"); got != 3 {
+		t.Errorf("got verbosity %d, want 3", got)
+	}
+
+	_, err = run(newApp(), "deploy --env dev --env prod")
+
+	var repeated *RepeatedFlagError
+	if !errors.As(err, &repeated) || repeated.Flag != "env" || !errors.Is(err, ErrorRepeatedFlag) {
+		t.Fatalf("got %v, want a repeated flag error", err)
+	}
+
+	app := newApp()
+	app.AllowRepeatedFlags = true
+
+	cmd, err = run(app, "deploy --env dev --env prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cmd.Flags.GetFlagString("env", ""); got != "prod" {
+		t.Errorf("got %q, want the last value", got)
+	}
+}
+
+func TestSplitFlagToken(t *testing.T) {
+	schema := flagSchema{
+		"tar": {
+			"x":       FlagTypeBool,
+			"v":       FlagTypeCounter,
+			"f":       FlagTypeString,
+			"o":       FlagTypeString,
+			"force":   FlagTypeBool,
+			"verbose": FlagTypeCounter,
+			"output":  FlagTypeString,
+		},
+	}
+	file := "file"
+
+	tests := []struct {
+		token    string
+		want     []flagOccurrence
+		consumed bool
+	}{
+		{"-xvf", []flagOccurrence{{"x", "true"}, {"v", "true"}, {"f", "file"}}, true},
+		{"-vvv", []flagOccurrence{{"v", "true"}, {"v", "true"}, {"v", "true"}}, false},
+		{"-oout.txt", []flagOccurrence{{"o", "out.txt"}}, false},
+		{"-xo=out.txt", []flagOccurrence{{"x", "true"}, {"o", "out.txt"}}, false},
+		{"-x=false", []flagOccurrence{{"x", "false"}}, false},
+		{"-o", []flagOccurrence{{"o", "file"}}, true},
+		{"--output=a=b", []flagOccurrence{{"output", "a=b"}}, false},
+		{"--force", []flagOccurrence{{"force", "true"}}, false},
+		{"--no-force", []flagOccurrence{{"force", "false"}}, false},
+		{"--no-output", []flagOccurrence{{"no-output", "true"}}, false},
+		{"-abc", []flagOccurrence{{"abc", "true"}}, false},
+	}
+
+	for _, tt := range tests {
+		got, consumed := splitFlagToken(schema, "tar", tt.token, &file)
+		if !reflect.DeepEqual(got, tt.want) || consumed != tt.consumed {
+			t.Errorf("splitFlagToken(%q) = %v, %v, want %v, %v", tt.token, got, consumed, tt.want, tt.consumed)
+		}
+	}
+}
+
+func TestCreateCommandFlow_InheritedFlags(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := &Command{
+		Name:  "list",
+		Flags: Flags{&FlagValue[string]{Name: "output"}},
+	}
+	users := &Command{
+		Name:            "users",
+		PersistentFlags: Flags{&FlagValue[string]{Name: "profile", Alias: "p"}, &FlagValue[string]{Name: "output"}},
+		Subcommands:     Commands{list},
+	}
+	app := &App{
+		Name:        "admin",
+		Commands:    Commands{users},
+		GlobalFlags: Flags{&FlagValue[Counter]{Name: "verbose", Alias: "v"}},
+	}
+
+	ast, err := app.parse("users -v list -p prod --output json -v")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, globals, _, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := createPreContext(flow[1], ast)
+	ctx.flags = visibleFlags(globals, flow, 1)
+
+	if got := ctx.GetFlagString("profile", ""); got != "prod" {
+		t.Errorf("got profile %q, want prod", got)
+	}
+
+	if got := MustFlag[Counter](ctx, "verbose"); got != 2 {
+		t.Errorf("got verbosity %d, want 2", got)
+	}
+
+	if got := ctx.GetFlagCounter("verbose"); got != 2 {
+		t.Errorf("got verbosity %d from GetFlagCounter, want 2", got)
+	}
+
+	if got := ctx.GetFlagString("output", ""); got != "json" {
+		t.Errorf("got output %q, want the value of the own flag", got)
+	}
+
+	if got := flow[0].PersistentFlags.GetFlagString("output", ""); got != "" {
+		t.Errorf("the shadowed persistent flag must not be set, got %q", got)
+	}
+
+	help, err := helpCommand(flow[1], ast.CommandTree, inheritedFlags(globals, flow, 1), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(help, L(i18n_help_inherited_flags)) || !strings.Contains(help, "profile") ||
+		!strings.Contains(help, "verbose") {
+		t.Fatalf("the help must show the inherited flags:\n%s", help)
+	}
+}
+
+func TestCreateCommandFlow_SameNamedSubcommands(t *testing.T) {
+	usersList := &Command{
+		Name:      "list",
+		Flags:     Flags{&FlagValue[bool]{Name: "admins"}},
+		Arguments: []*Argument{&Argument{Name: "filter"}},
+	}
+	groupsList := &Command{
+		Name:  "list",
+		Flags: Flags{&FlagValue[int]{Name: "limit"}},
+	}
+	app := &App{Commands: Commands{
+		{Name: "users", Subcommands: Commands{usersList}},
+		{Name: "groups", Subcommands: Commands{groupsList}},
+	}}
+
+	ast, err := app.parse("groups list --limit 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.CommandPath != "groups/list" {
+		t.Fatalf("got command path %q, want groups/list", ast.CommandPath)
+	}
+
+	flow, _, _, err := createCommandFlow(app, ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if flow[0].Name != "groups" || flow[1].Flags.GetFlagInt("limit", 0) != 5 {
+		t.Fatal("the list subcommand of groups must be run")
+	}
+
+	_, err = app.parse("users list")
+	if !errors.Is(err, ErrorArgumentNotFound) {
+		t.Fatalf("got %v, want the arguments of users/list to be required", err)
+	}
+}
+
+func TestParseCommand_AliasesAndPrefixes(t *testing.T) {
+	commands := commandsSchema{
+		{Name: "deploy", Aliases: []string{"dep"}, Subcommands: []commandSchema{
+			{Name: "status", Aliases: []string{"st"}},
+			{Name: "start"},
+		}},
+		{Name: "delete"},
+	}
+
+	tests := []struct {
+		input string
+		opts  parseOptions
+		want  []string
+		err   error
+	}{
+		{"dep st", parseOptions{}, []string{"deploy", "status"}, nil},
+		{"deploy sta", parseOptions{}, nil, ErrorSubcommandUnknown},
+		{"deploy stat", parseOptions{AllowPrefixMatch: true}, []string{"deploy", "status"}, nil},
+		{"deploy st", parseOptions{AllowPrefixMatch: true}, []string{"deploy", "status"}, nil},
+		{"deploy sta", parseOptions{AllowPrefixMatch: true}, nil, ErrorAmbiguousCommand},
+		{"de status", parseOptions{AllowPrefixMatch: true}, nil, ErrorAmbiguousCommand},
+		{"del", parseOptions{AllowPrefixMatch: true}, []string{"delete"}, nil},
+		{"DEP Status", parseOptions{}, nil, ErrorUnknownCommand},
+		{"DEP Status", parseOptions{CaseInsensitive: true}, []string{"deploy", "status"}, nil},
+	}
+
+	for _, tt := range tests {
+		ast, err := parseCommand(commands, flagSchema{}, argsSchema{}, tt.input, tt.opts)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("parseCommand(%q) returns %v, want %v", tt.input, err, tt.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseCommand(%q) returns %v", tt.input, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(ast.CommandTree, tt.want) {
+			t.Errorf("parseCommand(%q) resolves to %v, want %v", tt.input, ast.CommandTree, tt.want)
+		}
+	}
+}
+
+func TestParseCommand_Strict(t *testing.T) {
+	newApp := func() *App {
+		return &App{Commands: Commands{
+			{
+				Name:      "rm",
+				Flags:     Flags{&FlagValue[bool]{Name: "dry-run", Alias: "n"}, &FlagValue[bool]{Name: "force"}},
+				Arguments: []*Argument{&Argument{Name: "path"}},
+			},
+			{
+				Name:            "exec",
+				Flags:           Flags{&FlagValue[bool]{Name: "tty", Alias: "t"}},
+				PassThroughArgs: true,
+			},
+		}}
+	}
+
+	_, err := newApp().parse("rm --dryrun /tmp")
+
+	var unknown *UnknownFlagError
+	if !errors.As(err, &unknown) || !errors.Is(err, ErrorUnknownFlag) || unknown.Flag != "dryrun" {
+		t.Fatalf("got %v, want an unknown flag error", err)
+	}
+
+	if !reflect.DeepEqual(unknown.Suggestions, []string{"--dry-run"}) {
+		t.Errorf("got suggestions %v, want [--dry-run]", unknown.Suggestions)
+	}
+
+	_, err = newApp().parse("rm -nx /tmp")
+	if !errors.As(err, &unknown) || unknown.Flag != "x" {
+		t.Fatalf("got %v, want an unknown flag error for -x", err)
+	}
+
+	_, err = newApp().parse("rm /tmp /var")
+
+	var tooMany *TooManyArgumentsError
+	if !errors.As(err, &tooMany) || !errors.Is(err, ErrorTooManyArguments) || !reflect.DeepEqual(tooMany.Args, []string{"/var"}) {
+		t.Fatalf("got %v, want a too many arguments error", err)
+	}
+
+	ast, err := newApp().parse("rm -- -5")
+	if err != nil || ast.Arguments[0].Value != "-5" {
+		t.Fatalf("got %v, %v, want -5 as the path", ast, err)
+	}
+
+	ast, err = newApp().parse("exec -t kubectl get pods -n default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ast.Args, []string{"kubectl", "get", "pods", "-n", "default"}) {
+		t.Errorf("got args %v, want the unknown flags passed through", ast.Args)
+	}
+
+	app := newApp()
+	app.AllowUnknownFlags = true
+
+	_, err = app.parse("rm --dryrun /tmp")
+	if err != nil {
+		t.Fatalf("unknown flags must be accepted: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package replyme
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// pipelineStage is a command of a pipeline like `users list | filter --role admin > admins.json`,
+// with the files its input and output are redirected to.
+type pipelineStage struct {
+	command string
+	// File after `<`
+	stdin string
+	// File after `>` or `>>`
+	stdout       string
+	appendStdout bool
+}
+
+// parsePipeline splits the command at `|` and takes the `<`, `>` and `>>` redirections out of it.
+// Operators that are quoted or escaped are left in the command. If e is set, the redirect targets
+// are expanded like the words of the command.
+//
+//nolint:cyclop
+func parsePipeline(input string, e expander) ([]pipelineStage, error) {
+	words := splitPipelineWords(input)
+	if len(words) == 0 {
+		return []pipelineStage{{command: input}}, nil
+	}
+
+	var stages []pipelineStage
+
+	var stage pipelineStage
+
+	var command []string
+
+	end := func(token string) error {
+		if len(command) == 0 {
+			return newErrorSyntax(token)
+		}
+
+		stage.command = strings.Join(command, " ")
+		stages = append(stages, stage)
+		stage, command = pipelineStage{}, nil
+
+		return nil
+	}
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		switch word {
+		case "|":
+			if err := end(word); err != nil {
+				return nil, err
+			}
+		case "<", ">", ">>":
+			if i+1 >= len(words) || isPipelineOperator(words[i+1]) {
+				return nil, newErrorSyntax(word)
+			}
+
+			i++
+
+			target, err := tokenizeExpand(words[i], e, false)
+			if err != nil {
+				return nil, err
+			}
+
+			if word == "<" {
+				stage.stdin = strings.Join(target, " ")
+			} else {
+				stage.stdout = strings.Join(target, " ")
+				stage.appendStdout = word == ">>"
+			}
+		default:
+			command = append(command, word)
+		}
+	}
+
+	if err := end("|"); err != nil {
+		return nil, err
+	}
+
+	return stages, nil
+}
+
+func isPipelineOperator(word string) bool {
+	return word == "|" || word == "<" || word == ">" || word == ">>"
+}
+
+// splitPipelineWords splits the command into words at spaces and around unquoted `|`, `<`, `>` and `>>`.
+// Unlike tokenize, it keeps the quotes and escapes, so that the words can be joined and tokenized again.
+//
+//nolint:cyclop
+func splitPipelineWords(input string) []string {
+	var words []string
+
+	var current strings.Builder
+
+	var inQuote bool
+
+	var quoteChar rune
+
+	var escape bool
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case escape:
+			escape = false
+		case r == '\\':
+			escape = true
+		case r == '$' && (!inQuote || quoteChar == '"'):
+			// The operators inside a command substitution belong to it
+			if end := skipSubstitution(runes, i); end != -1 {
+				current.WriteString(string(runes[i : end+1]))
+				i = end
+
+				continue
+			}
+		case inQuote:
+			inQuote = r != quoteChar
+		case r == '"' || r == '\'':
+			inQuote = true
+			quoteChar = r
+		case unicode.IsSpace(r):
+			flush()
+
+			continue
+		case r == '|' || r == '<' || r == '>':
+			flush()
+
+			if r == '>' && i+1 < len(runes) && runes[i+1] == '>' {
+				words = append(words, ">>")
+				i++
+			} else {
+				words = append(words, string(r))
+			}
+
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	flush()
+
+	return words
+}
+
+// runPipeline runs the stages one after another, passing the output of each stage to the next one
+// through Context.Stdin. The first stage reads p.stdin and the last one writes to p.stdout,
+// unless they are redirected to files. The pipeline stops at the first stage that fails.
+func runPipeline(p fullRunCommandParams, stages []pipelineStage) error {
+	input := p.stdin
+
+	for i, stage := range stages {
+		stdin, piped := input, i > 0
+
+		var stdout io.Writer = p.stdout
+
+		var next *bytes.Buffer
+
+		if i < len(stages)-1 {
+			next = &bytes.Buffer{}
+			stdout = next
+		}
+
+		var files []*os.File
+
+		if stage.stdin != "" {
+			f, err := os.Open(stage.stdin)
+			if err != nil {
+				return err
+			}
+
+			files = append(files, f)
+			stdin, piped = f, true
+		}
+
+		if stage.stdout != "" {
+			flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			if stage.appendStdout {
+				flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			}
+
+			f, err := os.OpenFile(stage.stdout, flags, 0o644) //nolint:mnd
+			if err != nil {
+				closeFiles(files)
+
+				return err
+			}
+
+			files = append(files, f)
+			stdout = f
+		}
+
+		err := runStage(p, stage.command, stdin, stdout, piped)
+
+		closeFiles(files)
+
+		if err != nil {
+			return err
+		}
+
+		// The next stage reads nothing if the output went to a file, as in the shell
+		input = strings.NewReader("")
+		if next != nil && stage.stdout == "" {
+			input = next
+		}
+	}
+
+	if f, ok := p.stdout.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
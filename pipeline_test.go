@@ -0,0 +1,167 @@
+package replyme
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []pipelineStage
+	}{
+		{"users list", []pipelineStage{{command: "users list"}}},
+		{
+			"users list | filter --role admin > admins.json",
+			[]pipelineStage{{command: "users list"}, {command: "filter --role admin", stdout: "admins.json"}},
+		},
+		{
+			`import <"my users.csv"|count>>log.txt`,
+			[]pipelineStage{{command: "import", stdin: "my users.csv"}, {command: "count", stdout: "log.txt", appendStdout: true}},
+		},
+		{`echo "a | b" c\>d`, []pipelineStage{{command: `echo "a | b" c\>d`}}},
+		{"echo $(users list | count) > n.txt", []pipelineStage{{command: "echo $(users list | count)", stdout: "n.txt"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePipeline(tt.input, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePipeline(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"| a", "a |", "a >", "a > | b", "a | | b"} {
+		if _, err := parsePipeline(input, nil); !errors.Is(err, ErrorChainSyntax) {
+			t.Errorf("parsePipeline(%q): got %v, want a syntax error", input, err)
+		}
+	}
+}
+
+func newPipelineApp() *App {
+	return &App{
+		Name: "test",
+		Commands: Commands{
+			{
+				Name: "users",
+				Subcommands: Commands{{
+					Name: "list",
+					Action: func(ctx *Context) error {
+						_, err := fmt.Fprint(ctx.Stdout(), "alice admin\nbob user\ncarol admin\n")
+
+						return err
+					},
+				}},
+			},
+			{
+				Name:       "filter",
+				ReadsStdin: true,
+				Flags:      Flags{&FlagValue[string]{Name: "role"}},
+				Action: func(ctx *Context) error {
+					scanner := bufio.NewScanner(ctx.Stdin())
+					for scanner.Scan() {
+						if strings.HasSuffix(scanner.Text(), " "+ctx.GetFlagString("role", "")) {
+							fmt.Fprintln(ctx.Stdout(), strings.Fields(scanner.Text())[0])
+						}
+					}
+
+					return scanner.Err()
+				},
+			},
+			{Name: "noop"},
+		},
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	app := newPipelineApp()
+	file := filepath.Join(t.TempDir(), "admins.txt")
+	logs := make(chan log, 10)
+
+	run := func(command string) (string, error) {
+		buf := &bytes.Buffer{}
+		err := fullRunCommand(fullRunCommandParams{command, app, logs, nil, buf, buf, func(logMsg) {}, nil, nil, true, nil, nil, 0})
+
+		return buf.String(), err
+	}
+
+	out, err := run("users list | filter --role admin")
+	if err != nil || out != "alice\ncarol\n" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+
+	if _, err = run("users list | filter --role admin > " + file); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = run("users list | filter --role user >> " + file); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil || string(data) != "alice\ncarol\nbob\n" {
+		t.Fatalf("got file %q, %v", data, err)
+	}
+
+	out, err = run("filter --role admin < " + file)
+	if err != nil || out != "" {
+		t.Errorf("got %q, %v, want nothing, the file has no roles", out, err)
+	}
+
+	_, err = run("users list | noop")
+	if !errors.Is(err, ErrorStdinNotRead) {
+		t.Errorf("got %v, want a stdin error", err)
+	}
+
+	_, err = run("filter < " + filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want a missing file error", err)
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	logs := make(chan log, 10)
+	w := &logWriter{command: "cmd", logType: logTypeMessage, logsChan: logs}
+
+	fmt.Fprint(w, "one\ntw")
+	fmt.Fprint(w, "o\nthree")
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	close(logs)
+
+	var got []string
+	for l := range logs {
+		got = append(got, l.Message)
+	}
+
+	if !reflect.DeepEqual(got, []string{"one", "two", "three"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCLICommandLine(t *testing.T) {
+	args := []string{"users", "list", "|", "filter", "--name", "Ann Lee", ">", "a>b.txt"}
+
+	got := cliCommandLine(args, true)
+	if want := `users list | filter --name "Ann Lee" > "a>b.txt"`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	got = cliCommandLine([]string{"cmp", "a", ">", "b"}, false)
+	if want := `cmp a ">" b`; got != want {
+		t.Errorf("got %s, want the operators to be passed to the command without CLIPipelines", got)
+	}
+}
@@ -0,0 +1,591 @@
+package replyme
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// statement is a node of a parsed command line or script.
+type statement interface {
+	// pos returns the number of the line the statement starts at.
+	pos() int
+	String() string
+}
+
+// commandStatement is a pipeline like `users list | filter --role admin > admins.json`,
+// or a call of a function defined with `func`.
+type commandStatement struct {
+	line int
+	// The words keep their quotes and escapes, the command is tokenized when it runs
+	words []string
+}
+
+// chainStatement is a chain like `build && deploy || notify failed`.
+type chainStatement struct {
+	line     int
+	segments []chainSegment
+}
+
+// ifStatement is `if a; then b; elif c; then d; else e; fi`.
+type ifStatement struct {
+	line     int
+	branches []ifBranch
+	// The body of `else`, nil if there is none
+	otherwise []statement
+}
+
+type ifBranch struct {
+	condition []statement
+	body      []statement
+}
+
+// backgroundStatement is a statement started in the background with `&`, e.g. `port-forward db &`.
+type backgroundStatement struct {
+	line int
+	stmt statement
+}
+
+// forStatement is `for name in words; do body; done`.
+type forStatement struct {
+	line  int
+	name  string
+	words []string
+	body  []statement
+}
+
+// funcStatement is `func name() { body; }`.
+type funcStatement struct {
+	line int
+	name string
+	body []statement
+}
+
+func (s *commandStatement) pos() int    { return s.line }
+func (s *chainStatement) pos() int      { return s.line }
+func (s *ifStatement) pos() int         { return s.line }
+func (s *backgroundStatement) pos() int { return s.line }
+func (s *forStatement) pos() int        { return s.line }
+func (s *funcStatement) pos() int       { return s.line }
+
+func (s *commandStatement) String() string {
+	return strings.Join(s.words, " ")
+}
+
+func (s *chainStatement) String() string {
+	var b strings.Builder
+
+	for i, segment := range s.segments {
+		if i > 0 {
+			b.WriteString(" " + segment.op.String() + " ")
+		}
+
+		b.WriteString(segment.stmt.String())
+	}
+
+	return b.String()
+}
+
+func (s *ifStatement) String() string {
+	var b strings.Builder
+
+	for i, branch := range s.branches {
+		keyword := "if"
+		if i > 0 {
+			keyword = " elif"
+		}
+
+		fmt.Fprintf(&b, "%s %s then %s", keyword, terminatedList(branch.condition), terminatedList(branch.body))
+	}
+
+	if s.otherwise != nil {
+		b.WriteString(" else " + terminatedList(s.otherwise))
+	}
+
+	b.WriteString(" fi")
+
+	return b.String()
+}
+
+func (s *backgroundStatement) String() string {
+	return s.stmt.String() + " &"
+}
+
+func (s *forStatement) String() string {
+	return fmt.Sprintf("for %s in %s; do %s done", s.name, strings.Join(s.words, " "), terminatedList(s.body))
+}
+
+func (s *funcStatement) String() string {
+	return fmt.Sprintf("func %s() { %s }", s.name, terminatedList(s.body))
+}
+
+func listString(list []statement) string {
+	var b strings.Builder
+
+	for i, s := range list {
+		if i > 0 {
+			// `&` separates the statements itself
+			if _, ok := list[i-1].(*backgroundStatement); ok {
+				b.WriteString(" ")
+			} else {
+				b.WriteString("; ")
+			}
+		}
+
+		b.WriteString(s.String())
+	}
+
+	return b.String()
+}
+
+// terminatedList returns the list followed by `;`, unless it ends with `&`, which terminates it itself.
+func terminatedList(list []statement) string {
+	if len(list) > 0 {
+		if _, ok := list[len(list)-1].(*backgroundStatement); ok {
+			return listString(list)
+		}
+	}
+
+	return listString(list) + ";"
+}
+
+type tokenKind uint8
+
+const (
+	tokenWord tokenKind = iota
+	// `;`
+	tokenSeparator
+	tokenNewline
+	tokenAnd
+	tokenOr
+	// `&`
+	tokenBackground
+	tokenEOF
+)
+
+// token is a word or an operator of a program. Words keep their quotes and escapes.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenNewline:
+		return "newline"
+	case tokenEOF:
+		return "end of input"
+	default:
+		return t.text
+	}
+}
+
+// lexProgram splits the lines into words and the `;`, `&`, `&&` and `||` operators.
+// `|`, `<`, `>` and `>>` are words, they are handled by parsePipeline when the command runs.
+func lexProgram(lines []scriptLine) []token {
+	var tokens []token
+
+	for _, line := range lines {
+		tokens = append(tokens, lexLine(line)...)
+		tokens = append(tokens, token{kind: tokenNewline, line: line.number})
+	}
+
+	last := 0
+	if len(lines) > 0 {
+		last = lines[len(lines)-1].number
+	}
+
+	return append(tokens, token{kind: tokenEOF, line: last})
+}
+
+//nolint:cyclop,funlen
+func lexLine(line scriptLine) []token {
+	var tokens []token
+
+	var current strings.Builder
+
+	var inQuote bool
+
+	var quoteChar rune
+
+	var escape bool
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, token{kind: tokenWord, text: current.String(), line: line.number})
+			current.Reset()
+		}
+	}
+
+	runes := []rune(line.text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case escape:
+			escape = false
+		case r == '\\':
+			escape = true
+		case r == '$' && (!inQuote || quoteChar == '"'):
+			// The operators inside a command substitution belong to it
+			if end := skipSubstitution(runes, i); end != -1 {
+				current.WriteString(string(runes[i : end+1]))
+				i = end
+
+				continue
+			}
+		case inQuote:
+			inQuote = r != quoteChar
+		case r == '"' || r == '\'':
+			inQuote = true
+			quoteChar = r
+		case unicode.IsSpace(r):
+			flush()
+
+			continue
+		case r == ';':
+			flush()
+
+			tokens = append(tokens, token{kind: tokenSeparator, text: ";", line: line.number})
+
+			continue
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			flush()
+
+			kind := tokenAnd
+			if r == '|' {
+				kind = tokenOr
+			}
+
+			tokens = append(tokens, token{kind: kind, text: string(runes[i : i+2]), line: line.number})
+			i++
+
+			continue
+		case r == '&':
+			flush()
+
+			tokens = append(tokens, token{kind: tokenBackground, text: "&", line: line.number})
+
+			continue
+		case r == '|' || r == '<' || r == '>':
+			flush()
+
+			text := string(r)
+			if r == '>' && i+1 < len(runes) && runes[i+1] == '>' {
+				text = ">>"
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, text: text, line: line.number})
+
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	flush()
+
+	return tokens
+}
+
+// isKeyword reports whether the word is a keyword when it starts a command.
+func isKeyword(word string) bool {
+	switch word {
+	case "if", "then", "elif", "else", "fi", "for", "in", "do", "done", "func", "{", "}":
+		return true
+	}
+
+	return false
+}
+
+// isListEnd reports whether the keyword ends the list of statements before it.
+func isListEnd(word string) bool {
+	switch word {
+	case "then", "elif", "else", "fi", "do", "done", "}":
+		return true
+	}
+
+	return false
+}
+
+// programParser parses the tokens of a command line or script into statements.
+type programParser struct {
+	tokens []token
+	i      int
+	// The line of the token a syntax error is found at
+	errLine int
+}
+
+func newProgramParser(lines []scriptLine) *programParser {
+	return &programParser{tokens: lexProgram(lines)}
+}
+
+// parseProgram parses a command line of the REPL.
+func parseProgram(input string) ([]statement, error) {
+	return newProgramParser([]scriptLine{{number: 1, text: input}}).parse()
+}
+
+func (p *programParser) parse() ([]statement, error) {
+	list, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+
+	return list, nil
+}
+
+func (p *programParser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *programParser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+
+	return t
+}
+
+func (p *programParser) skipNewlines() {
+	for p.peek().kind == tokenNewline {
+		p.next()
+	}
+}
+
+func (p *programParser) unexpected(t token) error {
+	p.errLine = t.line
+
+	return newErrorSyntax(t.String())
+}
+
+// keyword consumes the keyword or returns a syntax error.
+func (p *programParser) keyword(word string) error {
+	p.skipNewlines()
+
+	if t := p.next(); t.kind != tokenWord || t.text != word {
+		return p.unexpected(t)
+	}
+
+	return nil
+}
+
+// list parses statements separated by `;`, `&` or newlines, up to the end of the input
+// or a keyword that ends the list, like `fi` or `done`.
+func (p *programParser) list() ([]statement, error) {
+	var list []statement
+
+	for {
+		p.skipNewlines()
+
+		if t := p.peek(); t.kind == tokenEOF || (t.kind == tokenWord && isListEnd(t.text)) {
+			return list, nil
+		}
+
+		s, err := p.andOr()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := p.peek(); t.kind {
+		case tokenBackground:
+			p.next()
+
+			s = &backgroundStatement{line: s.pos(), stmt: s}
+		case tokenSeparator, tokenNewline:
+			p.next()
+		case tokenEOF:
+		default:
+			return nil, p.unexpected(t)
+		}
+
+		list = append(list, s)
+	}
+}
+
+// nonEmptyList parses a list that must have a statement, like the body of a loop.
+func (p *programParser) nonEmptyList() ([]statement, error) {
+	list, err := p.list()
+	if err == nil && len(list) == 0 {
+		err = p.unexpected(p.peek())
+	}
+
+	return list, err
+}
+
+func (p *programParser) andOr() (statement, error) {
+	first, err := p.command()
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []chainSegment{{op: chainSequence, stmt: first}}
+
+	for t := p.peek(); t.kind == tokenAnd || t.kind == tokenOr; t = p.peek() {
+		p.next()
+		p.skipNewlines()
+
+		op := chainAnd
+		if t.kind == tokenOr {
+			op = chainOr
+		}
+
+		if next := p.peek(); next.kind != tokenWord || isListEnd(next.text) {
+			return nil, p.unexpected(t)
+		}
+
+		s, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, chainSegment{op: op, stmt: s})
+	}
+
+	if len(segments) == 1 {
+		return first, nil
+	}
+
+	return &chainStatement{line: first.pos(), segments: segments}, nil
+}
+
+func (p *programParser) command() (statement, error) {
+	t := p.peek()
+	if t.kind != tokenWord {
+		return nil, p.unexpected(t)
+	}
+
+	switch t.text {
+	case "if":
+		return p.ifStatement()
+	case "for":
+		return p.forStatement()
+	case "func":
+		return p.funcStatement()
+	}
+
+	if isKeyword(t.text) {
+		return nil, p.unexpected(t)
+	}
+
+	s := &commandStatement{line: t.line}
+	for p.peek().kind == tokenWord {
+		s.words = append(s.words, p.next().text)
+	}
+
+	return s, nil
+}
+
+func (p *programParser) ifStatement() (statement, error) {
+	s := &ifStatement{line: p.next().line}
+
+	for {
+		condition, err := p.nonEmptyList()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.keyword("then"); err != nil {
+			return nil, err
+		}
+
+		body, err := p.nonEmptyList()
+		if err != nil {
+			return nil, err
+		}
+
+		s.branches = append(s.branches, ifBranch{condition: condition, body: body})
+
+		switch t := p.next(); t.text {
+		case "elif":
+			continue
+		case "else":
+			if s.otherwise, err = p.nonEmptyList(); err != nil {
+				return nil, err
+			}
+
+			return s, p.keyword("fi")
+		case "fi":
+			return s, nil
+		default:
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+func (p *programParser) forStatement() (statement, error) {
+	s := &forStatement{line: p.next().line}
+
+	name := p.next()
+	if name.kind != tokenWord || !isVariableName(name.text) {
+		return nil, p.unexpected(name)
+	}
+
+	s.name = name.text
+
+	if err := p.keyword("in"); err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenWord {
+		s.words = append(s.words, p.next().text)
+	}
+
+	if t := p.next(); t.kind != tokenSeparator && t.kind != tokenNewline {
+		return nil, p.unexpected(t)
+	}
+
+	if err := p.keyword("do"); err != nil {
+		return nil, err
+	}
+
+	body, err := p.nonEmptyList()
+	if err != nil {
+		return nil, err
+	}
+
+	s.body = body
+
+	return s, p.keyword("done")
+}
+
+func (p *programParser) funcStatement() (statement, error) {
+	s := &funcStatement{line: p.next().line}
+
+	name := p.next()
+	s.name = strings.TrimSuffix(name.text, "()")
+
+	if name.kind != tokenWord || !isFunctionName(s.name) {
+		return nil, p.unexpected(name)
+	}
+
+	if t := p.peek(); t.kind == tokenWord && t.text == "()" {
+		p.next()
+	}
+
+	if err := p.keyword("{"); err != nil {
+		return nil, err
+	}
+
+	body, err := p.nonEmptyList()
+	if err != nil {
+		return nil, err
+	}
+
+	s.body = body
+
+	return s, p.keyword("}")
+}
+
+// isFunctionName reports whether the name can be used for a function: a variable name that can also have `-`.
+func isFunctionName(name string) bool {
+	return name != "" && isVariableStart([]rune(name)[0]) && !isKeyword(name) &&
+		strings.IndexFunc(name, func(r rune) bool { return !isVariablePart(r) && r != '-' }) == -1
+}
@@ -0,0 +1,88 @@
+package replyme
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseProgram(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"if ping db; then migrate; fi", "if ping db; then migrate; fi"},
+		{
+			"if a && b; then c; elif d; then e; f; else g; fi || h",
+			"if a && b; then c; elif d; then e; f; else g; fi || h",
+		},
+		{
+			"for id in $(users list --ids) extra; do users disable $id; done",
+			"for id in $(users list --ids) extra; do users disable $id; done",
+		},
+		{"func deploy() { build && push $1; }", "func deploy() { build && push $1; }"},
+		{"func deploy-all () { deploy a; }", "func deploy-all() { deploy a; }"},
+		{"echo if then fi", "echo if then fi"},
+		{`"if" a`, `"if" a`},
+		{"for x in; do a; done", "for x in ; do a; done"},
+		{"port-forward db & build && deploy&", "port-forward db & build && deploy &"},
+		{"for x in a b; do sync $x & done; wait", "for x in a b; do sync $x & done; wait"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		if got := listString(program); got != tt.want {
+			t.Errorf("parseProgram(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"if a; then b",
+		"if a; then b fi",
+		"if a; then; fi",
+		"if; then a; fi",
+		"fi",
+		"then a",
+		"for 1x in a; do b; done",
+		"for x a; do b; done",
+		"for x in a do b; done",
+		"for x in a; do b; done c",
+		"func { a; }",
+		"func if() { a; }",
+		"func f() { a }",
+		"a && fi",
+	} {
+		if _, err := parseProgram(input); !errors.Is(err, ErrorChainSyntax) {
+			t.Errorf("parseProgram(%q): got %v, want a syntax error", input, err)
+		}
+	}
+}
+
+func TestParseProgram_Lines(t *testing.T) {
+	lines := []scriptLine{
+		{1, "for env in stage prod"},
+		{2, "do"},
+		{3, "  if deploy $env"},
+		{4, "  then notify $env"},
+		{5, "  fi"},
+		{6, "done"},
+		{8, "echo done"},
+	}
+
+	program, err := newProgramParser(lines).parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(program) != 2 || program[0].pos() != 1 || program[1].pos() != 8 {
+		t.Fatalf("got %d statements: %q", len(program), listString(program))
+	}
+
+	parser := newProgramParser([]scriptLine{{1, "if a"}, {2, "then b"}, {3, "done"}})
+	if _, err = parser.parse(); !errors.Is(err, ErrorChainSyntax) || parser.errLine != 3 {
+		t.Errorf("got %v on line %d, want a syntax error on line 3", err, parser.errLine)
+	}
+}
@@ -11,7 +11,29 @@ func Run(app *App) error {
 		return err
 	}
 
+	app.setConfigCommand()
+	app.setSessionCommands()
+	app.setAliasCommands()
+	app.setJobCommands()
+	app.setSourceCommand()
 	app.setHelpFlags()
+	app.setTimeoutFlags()
+
+	err = validateCommands(app.Commands, nil, app.GlobalFlags, app.parseOptions())
+	if err != nil {
+		return err
+	}
+
+	app.config, err = loadConfigFiles(app.ConfigFiles)
+	if err != nil {
+		return err
+	}
+
+	app.userAliases, err = loadAliases(app.AliasesFile)
+	if err != nil {
+		return err
+	}
+
 	_, err = tea.NewProgram(createModel(app), tea.WithAltScreen(), tea.WithMouseAllMotion()).Run()
 
 	return err
@@ -23,7 +45,20 @@ func RunCLI(app *App) error {
 		return err
 	}
 
+	app.setCompletionCommand()
+	app.setConfigCommand()
 	app.setHelpFlags()
+	app.setTimeoutFlags()
+
+	err = validateCommands(app.Commands, nil, app.GlobalFlags, app.parseOptions())
+	if err != nil {
+		return err
+	}
+
+	app.config, err = loadConfigFiles(app.ConfigFiles)
+	if err != nil {
+		return err
+	}
 
 	return cliRunner(app)
 }
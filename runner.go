@@ -1,58 +1,271 @@
 package replyme
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"slices"
+	"sync"
 	"time"
 )
 
-func createCommandFlow(app *App, ast *ASTNode) ([]*Command, error) {
-	cmds := make([]*Command, 0)
+// createCommandFlow parses the flags and arguments of the command line into copies of the commands of its path
+// and of the global flags, and returns them as the flow and the global flags of the run. The commands
+// of the application are not changed, so that runs of a command do not share their values,
+// e.g. a background job and the same command run in the foreground. The values are then mirrored
+// to the commands of the application, see mirrorRun. release must be called when the run ends.
+//
+//nolint:cyclop
+func createCommandFlow(app *App, ast *ASTNode) (cmds []*Command, globals Flags, release func(), err error) {
+	path, err := app.Commands.getCommandPath(ast.CommandTree)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	claims := &runClaims{}
 
-	if ast.Subcommands == nil || len(ast.Subcommands) == 0 {
-		cmd, err := app.Commands.getCommand(ast.Command)
+	defer func() {
 		if err != nil {
-			return nil, err
+			claims.release()
 		}
+	}()
 
-		err = insertDataInCommand(cmd, ast, false)
-		if err != nil {
-			return nil, err
+	cmds = make([]*Command, len(path))
+	for i, cmd := range path {
+		if cmds[i], err = cmd.runCopy(claims); err != nil {
+			return nil, nil, nil, err
 		}
+	}
 
-		cmds = append(cmds, cmd)
+	if globals, err = runFlags(app.GlobalFlags, claims); err != nil {
+		return nil, nil, nil, err
+	}
 
-		return cmds, nil
+	for i, cmd := range cmds {
+		err = insertDataInCommand(app, cmd, ast, i)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		err = insertInheritedFlags(app, cmd, cmd.PersistentFlags, ast, cmds, i)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	cmd, err := app.Commands.getCommand(ast.Command)
+	err = insertInheritedFlags(app, nil, globals, ast, cmds, 0)
 	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !isHelpRequested(cmds[len(cmds)-1]) {
+		err = checkRequiredFlags(cmds)
+		if err == nil {
+			err = checkRequired(app.Name, globals)
+		}
+
+		for i := 0; i < len(cmds) && err == nil; i++ {
+			err = checkFlagGroups(cmds[i].Name, cmds[i].flagGroups(), visibleFlags(globals, cmds, i))
+		}
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	mirrorRun(path, cmds, app.GlobalFlags, globals)
+
+	return cmds, globals, claims.release, nil
+}
+
+// flagCloner is implemented by the flags of replyme, which are copied for every run of a command.
+type flagCloner interface {
+	cloneFlag() FlagDefinition
+	mirrorFlag(run FlagDefinition)
+}
+
+// argCloner is implemented by the arguments of replyme, which are copied for every run of a command.
+type argCloner interface {
+	cloneArg() Arg
+	mirrorArg(run Arg)
+}
+
+// mirrorMu guards the values of the flags and arguments of the application, which mirrorRun sets.
+var mirrorMu sync.RWMutex
+
+// mirrorRun sets the values of the flags and arguments of the commands of the path and of the global flags
+// to the values of their copies in the run, so that the code that reads them directly, e.g. Argument.GetValue
+// in Action, gets the values of the last run that started.
+func mirrorRun(path, flow []*Command, globals, runGlobals Flags) {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+
+	mirrorFlags(globals, runGlobals)
+
+	for i, cmd := range path {
+		mirrorFlags(cmd.Flags, flow[i].Flags)
+		mirrorFlags(cmd.PersistentFlags, flow[i].PersistentFlags)
+
+		for j, arg := range cmd.Arguments {
+			arg.mirror(flow[i].Arguments[j])
+		}
+
+		for j, arg := range cmd.TypedArguments {
+			if cloner, ok := arg.(argCloner); ok {
+				cloner.mirrorArg(flow[i].TypedArguments[j])
+			}
+		}
+	}
+}
+
+func mirrorFlags(flags, run Flags) {
+	for i, flag := range flags {
+		if cloner, ok := flag.(flagCloner); ok {
+			cloner.mirrorFlag(run[i])
+		}
+	}
+}
+
+// sharedValues are the flags and arguments that cannot be copied and are used by a run, see runClaims.
+var (
+	sharedValues   = map[interface{}]bool{}
+	sharedValuesMu sync.Mutex
+)
+
+// runClaims are the flags and arguments of other implementations than the ones of replyme, which cannot be copied.
+// A run parses into them directly, so only one run can use them at a time.
+type runClaims []interface{}
+
+// claim marks the flag or argument as used by the run, or fails if another run uses it.
+func (c *runClaims) claim(value interface{}, name string) error {
+	sharedValuesMu.Lock()
+	defer sharedValuesMu.Unlock()
+
+	if sharedValues[value] {
+		return newErrorValueInUse(name)
+	}
+
+	sharedValues[value] = true
+	*c = append(*c, value)
+
+	return nil
+}
+
+// release releases the flags and arguments the run claimed.
+func (c *runClaims) release() {
+	sharedValuesMu.Lock()
+	defer sharedValuesMu.Unlock()
+
+	for _, value := range *c {
+		delete(sharedValues, value)
+	}
+
+	*c = nil
+}
+
+// runCopy returns a copy of the command with copies of its flags and arguments, into which a run is parsed.
+func (c *Command) runCopy(claims *runClaims) (*Command, error) {
+	var err error
+
+	run := *c
+
+	if run.Flags, err = runFlags(c.Flags, claims); err != nil {
 		return nil, err
 	}
 
-	err = insertDataInCommand(cmd, ast, true)
-	if err != nil {
+	if run.PersistentFlags, err = runFlags(c.PersistentFlags, claims); err != nil {
 		return nil, err
 	}
 
-	cmds = append(cmds, cmd)
+	run.Arguments = make([]*Argument, len(c.Arguments))
+	for i, arg := range c.Arguments {
+		run.Arguments[i] = arg.clone()
+	}
 
-	for i, subcommand := range ast.Subcommands {
-		cmd, err := app.Commands.getCommand(subcommand)
-		if err != nil {
+	if run.TypedArguments, err = runArgs(c.TypedArguments, claims); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// runFlags returns copies of the flags, into which a run is parsed.
+func runFlags(flags Flags, claims *runClaims) (Flags, error) {
+	if flags == nil {
+		return nil, nil
+	}
+
+	run := make(Flags, len(flags))
+
+	for i, flag := range flags {
+		if cloner, ok := flag.(flagCloner); ok {
+			run[i] = cloner.cloneFlag()
+
+			continue
+		}
+
+		// Other implementations cannot be copied, so the run uses them if no other run does
+		if err := claims.claim(flag, "--"+flag.GetName()); err != nil {
 			return nil, err
 		}
 
-		err = insertDataInCommand(cmd, ast, i != len(ast.Subcommands)-1)
-		if err != nil {
+		flag.Clear()
+		run[i] = flag
+	}
+
+	return run, nil
+}
+
+// runArgs returns copies of the arguments, into which a run is parsed.
+func runArgs(args Arguments, claims *runClaims) (Arguments, error) {
+	if args == nil {
+		return nil, nil
+	}
+
+	run := make(Arguments, len(args))
+
+	for i, arg := range args {
+		if cloner, ok := arg.(argCloner); ok {
+			run[i] = cloner.cloneArg()
+
+			continue
+		}
+
+		// Other implementations cannot be copied, so the run uses them if no other run does
+		if err := claims.claim(arg, arg.GetName()); err != nil {
 			return nil, err
 		}
 
-		cmds = append(cmds, cmd)
+		arg.Clear()
+		run[i] = arg
+	}
+
+	return run, nil
+}
+
+// visibleFlags returns the flags that the command at index i of the flow can read:
+// its own flags, the persistent flags of the command and its parents, and the global flags.
+func visibleFlags(globals Flags, flow []*Command, i int) Flags {
+	flags := append(slices.Clone(flow[i].Flags), flow[i].PersistentFlags...)
+
+	return append(flags, inheritedFlags(globals, flow, i)...)
+}
+
+// inheritedFlags returns the persistent flags of the parents of the command at index i of the flow
+// and the global flags, except the ones the command declares itself.
+func inheritedFlags(globals Flags, flow []*Command, i int) Flags {
+	var flags Flags
+
+	for j := i - 1; j >= 0; j-- {
+		flags = append(flags, flow[j].PersistentFlags...)
 	}
 
-	return cmds, nil
+	return slices.DeleteFunc(append(flags, globals...), flow[i].declaresFlag)
+}
+
+func isHelpRequested(command *Command) bool {
+	return command.Flags.GetFlagBool("help")
 }
 
 //nolint:cyclop
@@ -115,18 +328,16 @@ func runEnd(command *Command, ctx *Context) (err error) {
 }
 
 func appRunCleaner(app *App) {
+	for _, flag := range app.GlobalFlags {
+		flag.Clear()
+	}
+
 	commandCleaner(app.Commands)
 }
 
 func commandCleaner(commands []*Command) {
 	for _, command := range commands {
-		for _, flag := range command.Flags {
-			flag.Clear()
-		}
-
-		for _, arg := range command.Arguments {
-			arg.value = ""
-		}
+		clearCommand(command)
 
 		if command.Subcommands != nil {
 			commandCleaner(command.Subcommands)
@@ -134,90 +345,154 @@ func commandCleaner(commands []*Command) {
 	}
 }
 
+func clearCommand(command *Command) {
+	for _, flag := range append(slices.Clone(command.Flags), command.PersistentFlags...) {
+		flag.Clear()
+	}
+
+	for _, arg := range command.arguments() {
+		arg.Clear()
+	}
+}
+
 type fullRunCommandParams struct {
 	command    string
 	app        *App
 	logsChan   chan<- log
+	stdin      io.Reader
 	stdout     io.Writer
 	stderr     io.Writer
 	emitLog    func(logMsg)
 	emitTUI    func(TUIRequest)
 	emitTUICLI func(TUIRequest, chan<- bool)
 	isCLI      bool
+	// Cancels the command line, nil if it cannot be cancelled
+	ctx context.Context
+	// The arguments of the running function, `$1`, `$2`...
+	args []string
+	// The number of scripts run with `source` that the command line is in
+	sourceDepth int
 }
 
-//nolint:cyclop,funlen,lll
+// session returns the session of the application, or an empty one if the run has no application.
+func (p fullRunCommandParams) session() *session {
+	if p.app == nil {
+		return &session{}
+	}
+
+	return &p.app.session
+}
+
+func (p fullRunCommandParams) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+
+	return p.ctx
+}
+
+// fullRunCommand runs the command line, which can be a pipeline with redirections.
 func fullRunCommand(p fullRunCommandParams) error {
-	defer func() {
-		appRunCleaner(p.app)
-	}()
+	stages, err := parsePipeline(p.command, p.expander())
+	if err != nil {
+		return err
+	}
+
+	return runPipeline(p, stages)
+}
 
-	ast, err := parseCommand(createCommandSchema(p.app.Commands),
-		createFlagSchema(p.app.Commands), createArgsSchema(p.app.Commands), p.command)
+// expander returns the expander of the session variables, or nil in the CLI, where the shell has already expanded them.
+func (p fullRunCommandParams) expander() expander {
+	if p.isCLI {
+		return nil
+	}
+
+	return sessionExpander{p}
+}
+
+// runStage runs a command of the pipeline. piped is whether its input comes from another command or a file.
+//
+//nolint:cyclop,funlen
+func runStage(p fullRunCommandParams, command string, stdin io.Reader, stdout io.Writer, piped bool) error {
+	opts := p.app.parseOptions()
+	opts.Expander = p.expander()
+
+	ast, err := p.app.parseWith(command, opts)
 	if err != nil {
 		return err
 	}
 
-	flow, err := createCommandFlow(p.app, ast)
+	flow, globals, release, err := createCommandFlow(p.app, ast)
 	if err != nil {
 		return err
 	}
 
-	if len(flow) > 0 {
-		flags := flow[len(flow)-1].Flags
-		if flagI := slices.IndexFunc(flags, func(f Flag) bool {
-			if f.GetName() == "help" {
-				if d, err := f.ParsedValue(); err == nil && d.(bool) && d.(bool) == true {
-					return true
-				}
-			}
+	// The run releases the flow when it ends, which can be after runStage returns if it times out
+	running := false
 
-			return false
-		}); flagI != -1 {
-			help, err := helpCommand(flow[len(flow)-1])
-			if err != nil {
-				p.logsChan <- log{
-					logTypeError,
-					p.command,
-					"ERROR",
-					err,
-					time.Now(),
-				}
+	defer func() {
+		if !running {
+			release()
+		}
+	}()
 
-				return nil
-			}
-			p.logsChan <- log{
-				logTypeMessage,
-				p.command,
-				help,
-				nil,
-				time.Now(),
-			}
+	if len(flow) > 0 && isHelpRequested(flow[len(flow)-1]) {
+		help, err := helpCommand(flow[len(flow)-1], ast.CommandTree, inheritedFlags(globals, flow, len(flow)-1), p.app.EnvPrefix)
+		if err != nil {
 			p.logsChan <- log{
-				logTypeCommandSuccess,
+				logTypeError,
 				p.command,
-				p.command,
-				nil,
+				"ERROR",
+				err,
 				time.Now(),
 			}
 
 			return nil
 		}
+		p.logsChan <- log{
+			logTypeMessage,
+			p.command,
+			help,
+			nil,
+			time.Now(),
+		}
+
+		return nil
 	}
 
-	for _, cmd := range flow {
-		ctx := createPreContext(cmd, ast)
+	if piped && !flow[len(flow)-1].ReadsStdin {
+		return newErrorStdinNotRead(commandPath(ast.CommandTree))
+	}
+
+	running = true
+
+	return p.app.runWithTimeout(p.context(), commandPath(ast.CommandTree), flow, func(ctx context.Context, out *runOutput) error {
+		defer release()
+
+		return runFlow(ctx, out.params(p), flow, globals, ast, stdin, out.writer(stdout))
+	})
+}
+
+// runFlow runs the actions of the commands of the flow, from the top-level command to the one that is run,
+// and then their OnEnd functions in the reverse order. The Contexts of the commands are cancelled with parent.
+func runFlow(parent context.Context, p fullRunCommandParams, flow []*Command, globals Flags, ast *ASTNode, stdin io.Reader, stdout io.Writer) error {
+	for i, cmd := range flow {
+		ctx := createContext(parent, cmd, ast)
+		ctx.flags = visibleFlags(globals, flow, i)
 		ctx.isCLI = p.isCLI
 		ctx.emitLog = p.emitLog
-		ctx.stdout = p.stdout
+		ctx.stdin = stdin
+		ctx.stdout = stdout
 		ctx.stderr = p.stderr
+		ctx.params = p
+		ctx.session = p.session()
 		if p.isCLI {
 			ctx.emitTUICLI = p.emitTUICLI
 		} else {
 			ctx.emitTUI = p.emitTUI
 		}
 
-		err = runActions(cmd, ctx)
+		err := runActions(cmd, ctx)
 		if err != nil {
 			if errors.Is(err, ErrorCommandPanic) {
 
@@ -227,60 +502,135 @@ func fullRunCommand(p fullRunCommandParams) error {
 		}
 	}
 
-	slices.Reverse(flow)
-
-	for _, cmd := range flow {
-		ctx := createPreContext(cmd, ast)
+	for i := len(flow) - 1; i >= 0; i-- {
+		cmd := flow[i]
+		ctx := createContext(parent, cmd, ast)
+		ctx.flags = visibleFlags(globals, flow, i)
 		ctx.isCLI = p.isCLI
 		ctx.emitLog = p.emitLog
-		ctx.stdout = p.stdout
+		ctx.stdin = stdin
+		ctx.stdout = stdout
 		ctx.stderr = p.stderr
+		ctx.params = p
+		ctx.session = p.session()
 		if p.isCLI {
 			ctx.emitTUICLI = p.emitTUICLI
 		} else {
 			ctx.emitTUI = p.emitTUI
 		}
 
-		err = runEnd(cmd, ctx)
+		err := runEnd(cmd, ctx)
 		if err != nil {
 			return err
 		}
 	}
-	p.logsChan <- log{
-		logTypeCommandSuccess,
-		p.command,
-		p.command,
-		nil,
-		time.Now(),
-	}
 
 	return nil
 }
 
-func (m *model) runCommand(command string) error {
-	err := fullRunCommand(fullRunCommandParams{
-		command, m.app, m.logsChan, m.stdout, m.stderr,
-		m.emitLog, m.emitTUI, nil, false,
-	})
-	m.runningCommand = ""
-	m.input.running = false
+// runCommand runs the pipeline p.command, writing its output to the logs. tag is added to the start
+// of the logs, e.g. the ID of a job.
+func (m *model) runCommand(p fullRunCommandParams, tag string) error {
+	p.stdout = &logWriter{command: tag + p.command, prefix: tag, logType: logTypeMessage, logsChan: m.logsChan}
+	stderr := &logWriter{command: tag + p.command, prefix: tag, logType: logTypeError, logsChan: m.logsChan}
+	p.stderr = stderr
 
-	return err
+	defer func() {
+		_ = stderr.Flush()
+	}()
+
+	return fullRunCommand(p)
+}
+
+// runCommandLine runs the command line entered in the REPL, which can have chains, `if`, `for` and `func`.
+// Cancelling ctx cancels the running command and stops the line. id is the number of the line, see model.lineID.
+func (m *model) runCommandLine(ctx context.Context, id int, line string) {
+	program, err := parseProgram(line)
+	if err != nil {
+		m.logsChan <- log{logTypeCommandRunning, line, line, nil, time.Now()}
+		m.reportCommandError(line, err)
+
+		return
+	}
+
+	_ = m.interpreter("", id).execList(fullRunCommandParams{
+		"", m.app, m.logsChan, nil, nil, nil,
+		m.logger(line, ""), m.emitTUI, nil, false, ctx, nil, 0,
+	}, program)
+}
+
+// interpreter returns the interpreter of the REPL. Every command that runs, e.g. in every iteration of a loop,
+// gets its own running, success or failure entry in the logs. The entries and output of a job start with its tag.
+// The commands of the command line with the number line report to Update that they start, the ones of a job do not.
+func (m *model) interpreter(tag string, line int) *interpreter {
+	return &interpreter{
+		run: func(p fullRunCommandParams) error {
+			label := tag + p.command
+			if tag == "" {
+				select {
+				case m.runningChan <- runningCommandMsg{line, p.command}:
+				case <-p.context().Done():
+				}
+			}
+
+			p.emitLog = m.logger(label, tag)
+
+			m.logsChan <- log{logTypeCommandRunning, label, label, nil, time.Now()}
+
+			err := m.runCommand(p, tag)
+			if err != nil {
+				m.reportCommandError(label, err)
+
+				return err
+			}
+
+			m.logsChan <- log{logTypeCommandSuccess, label, label, nil, time.Now()}
+
+			return nil
+		},
+		start: m.startJob,
+	}
+}
+
+// startJob runs the statement of `&` in the background. The job is cancelled with `kill`,
+// its result is reported in the logs when it ends.
+func (m *model) startJob(p fullRunCommandParams, s statement) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := m.app.jobs.start(s.String(), cancel)
+	tag := fmt.Sprintf("[%d] ", j.id)
+
+	p.ctx = ctx
+
+	m.logsChan <- log{logTypeMessage, tag + j.command, jobLine(j, false, nil), nil, time.Now()}
+
+	go func() {
+		err := m.interpreter(tag, 0).exec(p, s)
+
+		// The job is still running while its result is logged, so that the REPL reads the log before it stops ticking
+		m.logsChan <- log{logTypeMessage, tag + j.command, jobLine(j, true, err), nil, time.Now()}
+		j.finish(err)
+	}()
+
+	return nil
 }
 
 func runCommand(app *App, ctx *Context, command string) error {
-	ast, err := parseCommand(createCommandSchema(app.Commands),
-		createFlagSchema(app.Commands), createArgsSchema(app.Commands), command)
+	ast, err := app.parse(command)
 	if err != nil {
 		return err
 	}
 
-	flow, err := createCommandFlow(app, ast)
+	flow, globals, release, err := createCommandFlow(app, ast)
 	if err != nil {
 		return err
 	}
 
-	for _, cmd := range flow {
+	defer release()
+
+	for i, cmd := range flow {
+		ctx.command = cmd
+		ctx.flags = visibleFlags(globals, flow, i)
+
 		err = runActions(cmd, ctx)
 		if err != nil {
 			return err
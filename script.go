@@ -0,0 +1,414 @@
+package replyme
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const sourceCommandName = "source"
+
+// maxSourceDepth limits the nesting of scripts run with `source`, so that a script that sources itself
+// fails instead of running until the application runs out of files or memory.
+const maxSourceDepth = 100
+
+// ScriptParams - the parameters of RunScript.
+type ScriptParams struct {
+	// Name of the script shown in the errors, e.g. its file name
+	Name string
+	// Keeps running the script after a line fails. By default, the script stops at the first failed line,
+	// like a shell script with `set -e`
+	ContinueOnError bool
+	// Prints every line before it runs, like a shell script with `set -x`
+	Echo bool
+	// Answers to the prompts of the commands (SelectOne, InputText, Confirm...), one per line,
+	// in the order they are asked. If it is nil or runs out, a prompt fails the command
+	Answers io.Reader
+	// Output of the commands, os.Stdout by default
+	Stdout io.Writer
+	// Errors of the commands, os.Stderr by default
+	Stderr io.Writer
+	// Stops the script when it is cancelled, e.g. on a signal. Loops stop between their iterations
+	Context context.Context
+}
+
+// scriptLine is a command line of a script with the number of the line it starts at.
+type scriptLine struct {
+	number int
+	text   string
+}
+
+// RunScript runs the command lines of the script one after another without the TUI,
+// e.g. a runbook kept as a file. Lines can be chains and pipelines, have `if`, `for` and `func`,
+// and use the session variables, which are shared with the REPL and the scripts run with `source`.
+// A `#` starts a comment, and a `\` at the end of a line continues the command on the next one.
+func RunScript(app *App, script io.Reader, params ScriptParams) error {
+	err := i18nInit()
+	if err != nil {
+		return err
+	}
+
+	app.setConfigCommand()
+	app.setSessionCommands()
+	app.setAliasCommands()
+	app.setSourceCommand()
+	app.setHelpFlags()
+	app.setTimeoutFlags()
+
+	err = validateCommands(app.Commands, nil, app.GlobalFlags, app.parseOptions())
+	if err != nil {
+		return err
+	}
+
+	app.config, err = loadConfigFiles(app.ConfigFiles)
+	if err != nil {
+		return err
+	}
+
+	app.userAliases, err = loadAliases(app.AliasesFile)
+	if err != nil {
+		return err
+	}
+
+	if params.Stdout == nil {
+		params.Stdout = os.Stdout
+	}
+
+	if params.Stderr == nil {
+		params.Stderr = os.Stderr
+	}
+
+	logsChan := make(chan log)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for l := range logsChan {
+			if l.Type == logTypeCommandRunning || l.Type == logTypeCommandSuccess {
+				continue
+			}
+
+			fmt.Fprintln(params.Stdout, l.Render())
+		}
+	}()
+
+	answers := &scriptAnswers{}
+	if params.Answers != nil {
+		answers.scanner = bufio.NewScanner(params.Answers)
+	}
+
+	err = runScript(fullRunCommandParams{
+		"", app, logsChan, nil, params.Stdout, params.Stderr,
+		scriptLogger(params.Stdout, params.Stderr), answers.prompt, nil, false, params.Context, nil, 0,
+	}, script, params)
+
+	close(logsChan)
+	<-done
+
+	return err
+}
+
+// runScript runs the statements of the script with the settings of p. If params.ContinueOnError is set,
+// the failed statements are printed to p.stderr, and the error of the last one is returned, as in the shell.
+func runScript(p fullRunCommandParams, script io.Reader, params ScriptParams) error {
+	lines, err := readScript(script)
+	if err != nil {
+		return err
+	}
+
+	parser := newProgramParser(lines)
+
+	program, err := parser.parse()
+	if err != nil {
+		return &ScriptError{Script: params.Name, Line: parser.errLine, Err: err}
+	}
+
+	in := &interpreter{run: func(p fullRunCommandParams) error {
+		if params.Echo {
+			if _, err := fmt.Fprintf(p.stdout, "+ %s\n", p.command); err != nil {
+				return err
+			}
+		}
+
+		stages, err := parsePipeline(p.command, p.expander())
+		if err != nil {
+			return err
+		}
+
+		return runPipeline(p, stages)
+	}}
+
+	for _, s := range program {
+		if err = p.context().Err(); err != nil {
+			return err
+		}
+
+		err = in.exec(p, s)
+		if err == nil {
+			continue
+		}
+
+		err = &ScriptError{Script: params.Name, Line: s.pos(), Command: s.String(), Err: err}
+		if !params.ContinueOnError {
+			return err
+		}
+
+		if _, printErr := fmt.Fprintln(p.stderr, err); printErr != nil {
+			return printErr
+		}
+	}
+
+	return err
+}
+
+// readScript splits the script into command lines, joining the continued lines and skipping
+// the comments and the empty lines.
+func readScript(script io.Reader) ([]scriptLine, error) {
+	var lines []scriptLine
+
+	var current []string
+
+	start := 0
+	scanner := bufio.NewScanner(script)
+	// The lines are kept in memory anyway, so they are not limited to bufio.MaxScanTokenSize
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), math.MaxInt)
+
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if len(current) == 0 {
+			start = number
+		}
+
+		// As in the lexer, a backslash escapes the next one even inside quotes,
+		// so the line is continued if it ends with an odd number of backslashes
+		continued := (len(text)-len(strings.TrimRight(text, `\`)))%2 == 1
+		if continued {
+			text = strings.TrimSpace(strings.TrimSuffix(text, `\`))
+		}
+
+		if text != "" {
+			current = append(current, text)
+		}
+
+		if !continued && len(current) > 0 {
+			lines = append(lines, scriptLine{number: start, text: strings.Join(current, " ")})
+			current = nil
+		}
+	}
+
+	if len(current) > 0 {
+		lines = append(lines, scriptLine{number: start, text: strings.Join(current, " ")})
+	}
+
+	return lines, scanner.Err()
+}
+
+// stripComment removes the comment from the line. A comment starts with a `#` that is
+// at the start of a word and is not quoted or escaped.
+func stripComment(line string) string {
+	var quoteChar rune
+
+	escape := false
+	runes := []rune(line)
+
+	for i, r := range runes {
+		switch {
+		case escape:
+			escape = false
+		case r == '\\':
+			escape = true
+		case quoteChar != 0:
+			if r == quoteChar {
+				quoteChar = 0
+			}
+		case r == '"' || r == '\'':
+			quoteChar = r
+		case r == '#' && (i == 0 || unicode.IsSpace(runes[i-1])):
+			return string(runes[:i])
+		}
+	}
+
+	return line
+}
+
+// scriptLogger returns the emitLog of a script, which prints the messages of Context.Print and the like
+// to stdout, and the warnings and the errors to stderr.
+func scriptLogger(stdout, stderr io.Writer) func(logMsg) {
+	return func(msg logMsg) {
+		content := msg.Content
+
+		switch msg.Status {
+		case logMsgStatusPrintf, logMsgStatusWarnf, logMsgStatusErrorf, logMsgStatusPrintMarkdown:
+			if len(msg.Data) > 0 {
+				content = fmt.Sprintf(content, msg.Data...)
+			}
+		}
+
+		switch msg.Status {
+		case logMsgStatusWarn, logMsgStatusWarnf, logMsgStatusError, logMsgStatusErrorf:
+			fmt.Fprintln(stderr, content)
+		default:
+			fmt.Fprintln(stdout, content)
+		}
+	}
+}
+
+// scriptAnswers answers the prompts of the commands with the lines of ScriptParams.Answers.
+type scriptAnswers struct {
+	scanner *bufio.Scanner
+}
+
+// prompt is the emitTUI of a script.
+func (a *scriptAnswers) prompt(req TUIRequest) {
+	value, err := a.answer(req)
+	req.Response <- TUIResponse{Value: value, Err: err}
+}
+
+// answer parses the next answer as the value the prompt returns.
+//
+//nolint:cyclop,funlen
+func (a *scriptAnswers) answer(req TUIRequest) (interface{}, error) {
+	name := promptName(req.Payload)
+
+	if a.scanner == nil || !a.scanner.Scan() {
+		if a.scanner != nil && a.scanner.Err() != nil {
+			return nil, a.scanner.Err()
+		}
+
+		return nil, newErrorNoAnswer(name)
+	}
+
+	answer := a.scanner.Text()
+
+	switch p := req.Payload.(type) {
+	case TUIInputTextParams:
+		if p.MaxLength > 0 && len(answer) > p.MaxLength {
+			return nil, newErrorInvalidAnswer(name, answer)
+		}
+
+		if p.Validate != nil && !p.Validate(answer) {
+			return nil, newErrorInvalidAnswer(name, answer)
+		}
+
+		return answer, nil
+	case TUIInputIntParams:
+		value, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err != nil || (p.MinValue != 0 && value < p.MinValue) || (p.MaxValue != 0 && value > p.MaxValue) {
+			return nil, newErrorInvalidAnswer(name, answer)
+		}
+
+		if p.Validate != nil && !p.Validate(strconv.Itoa(value)) {
+			return nil, newErrorInvalidAnswer(name, answer)
+		}
+
+		return value, nil
+	case TUIConfirmParams:
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes", "true":
+			return true, nil
+		case "n", "no", "false":
+			return false, nil
+		}
+
+		return nil, newErrorInvalidAnswer(name, answer)
+	case TUISelectOneParams:
+		i := slices.IndexFunc(p.Items, func(item TUISelectItem) bool {
+			return item.ID == answer || item.Name == answer
+		})
+		if i == -1 {
+			return nil, newErrorInvalidAnswer(name, answer)
+		}
+
+		return TUISelectOneResult{SelectedID: p.Items[i].ID, SelectedItem: p.Items[i]}, nil
+	case TUIInputFileParams:
+		return readAnswerFile(p, answer)
+	}
+
+	return nil, newErrorNoAnswer(name)
+}
+
+// readAnswerFile returns the file the answer points to, as the file picker does.
+func readAnswerFile(p TUIInputFileParams, path string) (TUIInputFileResult, error) {
+	if len(p.Extensions) > 0 && !slices.ContainsFunc(p.Extensions, func(ext string) bool {
+		return strings.HasSuffix(path, ext)
+	}) {
+		return TUIInputFileResult{}, newErrorInvalidAnswer(p.Name, path)
+	}
+
+	if p.DoNotOutput {
+		return TUIInputFileResult{Path: path}, nil
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return TUIInputFileResult{}, err
+	}
+
+	return TUIInputFileResult{Path: path, File: file}, nil
+}
+
+func promptName(payload interface{}) string {
+	switch p := payload.(type) {
+	case TUISelectOneParams:
+		return p.Name
+	case TUIInputTextParams:
+		return p.Name
+	case TUIInputIntParams:
+		return p.Name
+	case TUIInputFileParams:
+		return p.Name
+	case TUIConfirmParams:
+		return p.Name
+	}
+
+	return ""
+}
+
+// setSourceCommand adds the `source` command, which runs a script in the current session,
+// unless the application has a command with this name.
+func (a *App) setSourceCommand() {
+	file := &Argument{Name: "file", Usage: L(i18n_app_source_file_usage)}
+
+	a.addBuiltinCommands(&Command{
+		Name:             sourceCommandName,
+		Usage:            L(i18n_app_source_usage),
+		Arguments:        []*Argument{file},
+		waitsForCommands: true,
+		Flags: Flags{
+			&FlagValue[bool]{Name: "continue", Alias: "c", Usage: L(i18n_app_source_continue_usage)},
+			&FlagValue[bool]{Name: "echo", Alias: "x", Usage: L(i18n_app_source_echo_usage)},
+		},
+		Action: func(ctx *Context) error {
+			params := ScriptParams{
+				Name:            MustGetArg[string](ctx, file.Name),
+				ContinueOnError: ctx.GetFlagBool("continue"),
+				Echo:            ctx.GetFlagBool("echo"),
+			}
+
+			p := ctx.params
+			if p.sourceDepth >= maxSourceDepth {
+				return newErrorSourceDepth(params.Name)
+			}
+
+			f, err := os.Open(params.Name)
+			if err != nil {
+				return err
+			}
+
+			defer f.Close()
+
+			p.stdin, p.stdout, p.stderr = nil, ctx.Stdout(), ctx.Stderr()
+			p.sourceDepth++
+
+			return runScript(p, f, params)
+		},
+	})
+}
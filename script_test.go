@@ -0,0 +1,241 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadScript(t *testing.T) {
+	script := `# Deploys the service
+build --target "linux # amd64"   # the comment is skipped
+
+deploy \
+  --env stage \
+  --tag \#1
+echo a\\
+echo "b\\\
+c"
+`
+
+	got, err := readScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []scriptLine{
+		{2, `build --target "linux # amd64"`},
+		{4, `deploy --env stage --tag \#1`},
+		{7, `echo a\\`},
+		{8, `echo "b\\ c"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readScript() = %q, want %q", got, want)
+	}
+
+	long := "echo " + strings.Repeat("a", 100000)
+
+	got, err = readScript(strings.NewReader(long + "\nfoo\n"))
+	if err != nil || len(got) != 2 || got[0].text != long {
+		t.Errorf("got %d lines, %v, want the lines longer than 64KB to be read", len(got), err)
+	}
+}
+
+func newScriptApp(prompted *[]string) *App {
+	app := newPipelineApp()
+	app.Commands = append(app.Commands,
+		&Command{
+			Name: "create",
+			Action: func(ctx *Context) error {
+				name, err := ctx.InputText(&TUIInputTextParams{Name: "Name"})
+				if err != nil {
+					return err
+				}
+
+				ok, err := ctx.Confirm(&TUIConfirmParams{Name: "Create " + name + "?"})
+				if err != nil {
+					return err
+				}
+
+				*prompted = append(*prompted, fmt.Sprint(name, " ", ok))
+				ctx.Set("id", name+"-1")
+
+				return nil
+			},
+		},
+		&Command{
+			Name:      "echo",
+			Arguments: []*Argument{&Argument{Name: "words", Optional: true, Variadic: true}},
+			Action: func(ctx *Context) error {
+				words, _ := GetArg[[]string](ctx, "words")
+				_, err := fmt.Fprintln(ctx.Stdout(), strings.Join(words, " "))
+
+				return err
+			},
+		},
+		&Command{
+			Name: "fail",
+			Action: func(*Context) error {
+				return errors.New("failed")
+			},
+		},
+	)
+
+	return app
+}
+
+func TestRunScript(t *testing.T) {
+	var prompted []string
+
+	script := "create\necho created $id\nusers list | filter --role admin\n"
+
+	out := &bytes.Buffer{}
+	err := RunScript(newScriptApp(&prompted), strings.NewReader(script), ScriptParams{
+		Name:    "deploy.rply",
+		Echo:    true,
+		Answers: strings.NewReader("web\nyes\n"),
+		Stdout:  out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "+ create\n+ echo created $id\ncreated web-1\n+ users list | filter --role admin\nalice\ncarol\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	if !reflect.DeepEqual(prompted, []string{"web true"}) {
+		t.Errorf("prompted %q", prompted)
+	}
+
+	err = RunScript(newScriptApp(&prompted), strings.NewReader("echo a\n\ncreate\necho b"), ScriptParams{
+		Name:   "deploy.rply",
+		Stdout: &bytes.Buffer{},
+	})
+
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 3 || !errors.Is(err, ErrorNoAnswer) {
+		t.Errorf("got %v, want a prompt error on line 3", err)
+	}
+
+	err = RunScript(newScriptApp(&prompted), strings.NewReader("create"), ScriptParams{
+		Answers: strings.NewReader("web\nmaybe\n"),
+		Stdout:  &bytes.Buffer{},
+	})
+	if !errors.Is(err, ErrorInvalidAnswer) {
+		t.Errorf("got %v, want an invalid answer error", err)
+	}
+}
+
+func TestRunScript_ContinueOnError(t *testing.T) {
+	out, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := RunScript(newScriptApp(nil), strings.NewReader("fail\necho a\nfail || echo b\n"), ScriptParams{
+		Name:            "s.rply",
+		ContinueOnError: true,
+		Stdout:          out,
+		Stderr:          stderr,
+	})
+	if err != nil {
+		t.Errorf("got %v, want nil, the last line succeeded", err)
+	}
+
+	if out.String() != "a\nb\n" || stderr.String() != "s.rply:1: fail: failed\n" {
+		t.Errorf("got %q and %q", out.String(), stderr.String())
+	}
+
+	out.Reset()
+
+	err = RunScript(newScriptApp(nil), strings.NewReader("fail\necho a"), ScriptParams{Stdout: out, Stderr: stderr})
+	if err == nil || out.Len() != 0 {
+		t.Errorf("got %v and %q, want the script to stop at the first line", err, out.String())
+	}
+}
+
+func TestSourceCommand(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "env.rply")
+	if err := os.WriteFile(file, []byte("set env=stage\necho sourced $env\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	err := RunScript(newScriptApp(nil), strings.NewReader("source --echo "+file+"\necho after $env"), ScriptParams{
+		Stdout: out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "+ set env=stage\n+ echo sourced $env\nsourced stage\nafter stage\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestSourceCommand_Nested(t *testing.T) {
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.rply")
+	outer := filepath.Join(dir, "outer.rply")
+	loop := filepath.Join(dir, "loop.rply")
+
+	for name, script := range map[string]string{
+		inner: "echo inner\n",
+		outer: "source " + quoteWord(inner) + "\necho outer\n",
+		loop:  "source " + quoteWord(loop) + "\n",
+	} {
+		if err := os.WriteFile(name, []byte(script), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := RunScript(newScriptApp(nil), strings.NewReader("source "+quoteWord(outer)), ScriptParams{Stdout: out}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "inner\nouter\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	err := RunScript(newScriptApp(nil), strings.NewReader("source "+quoteWord(loop)), ScriptParams{Stdout: out})
+	if !errors.Is(err, ErrorSourceDepth) {
+		t.Errorf("got %v, want the script that sources itself to fail", err)
+	}
+}
+
+func TestRunScript_ControlFlow(t *testing.T) {
+	script := `func greet() {
+  echo hello $1
+}
+
+for name in alice bob; do
+  if echo checking $name; then
+    greet $name
+  fi
+done
+fail || greet nobody
+`
+
+	out := &bytes.Buffer{}
+	if err := RunScript(newScriptApp(nil), strings.NewReader(script), ScriptParams{Stdout: out}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "checking alice\nhello alice\nchecking bob\nhello bob\nhello nobody\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	err := RunScript(newScriptApp(nil), strings.NewReader("echo a\nif echo b\nthen echo c\n"), ScriptParams{
+		Name:   "s.rply",
+		Stdout: &bytes.Buffer{},
+	})
+	if err == nil || err.Error() != "s.rply:3: syntax error: unexpected end of input" {
+		t.Errorf("got %v, want a syntax error on line 3", err)
+	}
+}
@@ -0,0 +1,242 @@
+package replyme
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	setCommandName   = "set"
+	unsetCommandName = "unset"
+	varsCommandName  = "vars"
+)
+
+// expander replaces `$name`, `${name}` and `$(command)` while the command line is tokenized.
+type expander interface {
+	// variable returns the value of the variable, or "" if it is not set.
+	variable(name string) string
+	// command runs the command line and returns its output.
+	command(line string) (string, error)
+}
+
+// sessionExpander expands the session variables, falling back to the environment,
+// and runs command substitutions with the settings of the running command.
+type sessionExpander struct {
+	p fullRunCommandParams
+}
+
+// variable returns the session variable, or the environment variable with this name.
+// `$1`, `$2`... are the arguments of the running function, `$@` is all of them and `$#` is their number.
+func (e sessionExpander) variable(name string) string {
+	switch {
+	case name == "@":
+		return strings.Join(e.p.args, " ")
+	case name == "#":
+		return strconv.Itoa(len(e.p.args))
+	case isPositional(name):
+		if n, _ := strconv.Atoi(name); n >= 1 && n <= len(e.p.args) {
+			return e.p.args[n-1]
+		}
+
+		return ""
+	}
+
+	if value, ok := e.p.session().get(name); ok {
+		return fmt.Sprint(value)
+	}
+
+	return os.Getenv(name)
+}
+
+// command runs the pipeline and returns its output without the trailing newlines.
+func (e sessionExpander) command(line string) (string, error) {
+	stages, err := parsePipeline(line, e)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	p := e.p
+	p.command = line
+	p.stdin = nil
+	p.stdout = buf
+
+	err = runPipeline(p, stages)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(buf.String(), "\r\n"), nil
+}
+
+// expandAt expands the `$` at the start of runes. It returns the value and the number of runes it replaces.
+// A `$` that does not start a variable or a substitution is kept as is.
+func expandAt(runes []rune, e expander) (string, int, error) {
+	if len(runes) < 2 { //nolint:mnd
+		return "$", 1, nil
+	}
+
+	switch {
+	case runes[1] == '(':
+		end := matchingParen(runes, 1)
+		if end == -1 {
+			return "", 0, ErrorUnclosedSubstitution
+		}
+
+		value, err := e.command(string(runes[2:end]))
+
+		return value, end + 1, err
+	case runes[1] == '{':
+		end := slices.Index(runes, '}')
+		if end == -1 {
+			return "", 0, ErrorUnclosedSubstitution
+		}
+
+		name := string(runes[2:end])
+		if !isVariableName(name) && !isPositional(name) {
+			return "", 0, newErrorInvalidVariable(name)
+		}
+
+		return e.variable(name), end + 1, nil
+	case isVariableStart(runes[1]):
+		end := 2
+		for end < len(runes) && isVariablePart(runes[end]) {
+			end++
+		}
+
+		return e.variable(string(runes[1:end])), end, nil
+	case runes[1] == '@' || runes[1] == '#' || isPositional(string(runes[1])):
+		return e.variable(string(runes[1])), 2, nil //nolint:mnd
+	default:
+		return "$", 1, nil
+	}
+}
+
+// matchingParen returns the index of the `)` that closes the `(` at the specified index, or -1.
+// Parentheses inside quotes are skipped.
+func matchingParen(runes []rune, open int) int {
+	depth := 0
+
+	var quoteChar rune
+
+	escape := false
+
+	for i := open; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case escape:
+			escape = false
+		case r == '\\':
+			escape = true
+		case quoteChar != 0:
+			if r == quoteChar {
+				quoteChar = 0
+			}
+		case r == '"' || r == '\'':
+			quoteChar = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// skipSubstitution returns the index of the `)` that closes the `$(` at the specified index,
+// or -1 if there is no substitution there. The lexers use it to keep the operators inside a substitution.
+func skipSubstitution(runes []rune, i int) int {
+	if runes[i] != '$' || i+1 >= len(runes) || runes[i+1] != '(' {
+		return -1
+	}
+
+	return matchingParen(runes, i+1)
+}
+
+func isVariableStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isVariablePart(r rune) bool {
+	return isVariableStart(r) || (r >= '0' && r <= '9')
+}
+
+// isPositional reports whether the name is the number of a function argument, like `1` in `$1`.
+func isPositional(name string) bool {
+	return name != "" && strings.Trim(name, "0123456789") == ""
+}
+
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if !isVariablePart(r) || (i == 0 && !isVariableStart(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setSessionCommands adds the `set`, `unset` and `vars` commands of the session variables,
+// unless the application has commands with these names.
+func (a *App) setSessionCommands() {
+	assignments := &Argument{Name: "assignments", Usage: L(i18n_app_set_assignments_usage), Variadic: true}
+	names := &Argument{Name: "names", Usage: L(i18n_app_unset_names_usage), Variadic: true}
+
+	commands := Commands{
+		{
+			Name:      setCommandName,
+			Usage:     L(i18n_app_set_usage),
+			Arguments: []*Argument{assignments},
+			Action: func(ctx *Context) error {
+				for _, assignment := range MustGetArg[[]string](ctx, assignments.Name) {
+					name, value, ok := strings.Cut(assignment, "=")
+					if !isVariableName(name) {
+						return newErrorInvalidVariable(name)
+					}
+
+					if !ok {
+						return newErrorInvalidAssignment(assignment)
+					}
+
+					ctx.Set(name, value)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      unsetCommandName,
+			Usage:     L(i18n_app_unset_usage),
+			Arguments: []*Argument{names},
+			Action: func(ctx *Context) error {
+				for _, name := range MustGetArg[[]string](ctx, names.Name) {
+					ctx.Delete(name)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  varsCommandName,
+			Usage: L(i18n_app_vars_usage),
+			Action: func(ctx *Context) error {
+				return ctx.memory().printVariables(ctx.Stdout())
+			},
+		},
+	}
+
+	a.addBuiltinCommands(commands...)
+}
@@ -0,0 +1,163 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testExpander map[string]string
+
+func (e testExpander) variable(name string) string {
+	return e[name]
+}
+
+func (e testExpander) command(line string) (string, error) {
+	if line == "fail" {
+		return "", errors.New("failed")
+	}
+
+	return strings.ToUpper(line), nil
+}
+
+func TestTokenizeExpand(t *testing.T) {
+	e := testExpander{"env": "stage", "msg": "hello world", "1": "first", "#": "2"}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"deploy --env $env", []string{"deploy", "--env", "stage"}},
+		{"echo ${env}1 $env1", []string{"echo", "stage1"}},
+		{"echo $msg", []string{"echo", "hello world"}},
+		{`echo "$env-$msg" '$env' \$env`, []string{"echo", "stage-hello world", "$env", "$env"}},
+		{"echo $ a$ $1 ${1}0 $#", []string{"echo", "$", "a$", "first", "first0", "2"}},
+		{`echo $(users "list" (all)) x`, []string{"echo", `USERS "LIST" (ALL)`, "x"}},
+	}
+
+	for _, tt := range tests {
+		got, err := tokenizeExpand(tt.input, e, false)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenizeExpand(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	got, err := tokenizeExpand(`for $msg "$msg" x$(a b)y`, e, true)
+	if want := []string{"for", "hello", "world", "hello world", "xA", "By"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenizeExpand() with split = %q, %v, want %q", got, err, want)
+	}
+
+	errorTests := []struct {
+		input string
+		want  error
+	}{
+		{"echo $(users", ErrorUnclosedSubstitution},
+		{"echo ${env", ErrorUnclosedSubstitution},
+		{"echo ${1x}", ErrorInvalidVariable},
+	}
+
+	for _, tt := range errorTests {
+		if _, err := tokenizeExpand(tt.input, e, false); !errors.Is(err, tt.want) {
+			t.Errorf("tokenizeExpand(%q): got %v, want %v", tt.input, err, tt.want)
+		}
+	}
+
+	if _, err := tokenizeExpand("echo $(fail)", e, false); err == nil {
+		t.Error("tokenizeExpand() returns no error when the substitution fails")
+	}
+}
+
+func TestSessionCommands(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("REPLYME_TEST_HOME", "/home/test")
+
+	app := newPipelineApp()
+	app.Commands = append(app.Commands, &Command{
+		Name:  "echo",
+		Usage: "Prints the arguments",
+		Arguments: []*Argument{
+			&Argument{Name: "words", Optional: true, Variadic: true},
+		},
+		Action: func(ctx *Context) error {
+			words, _ := GetArg[[]string](ctx, "words")
+			_, err := fmt.Fprintln(ctx.Stdout(), strings.Join(words, "|"))
+
+			return err
+		},
+	})
+	app.setSessionCommands()
+
+	logs := make(chan log, 10)
+
+	run := func(command string) (string, error) {
+		buf := &bytes.Buffer{}
+		err := fullRunCommand(fullRunCommandParams{command, app, logs, nil, buf, buf, func(logMsg) {}, nil, nil, false, nil, nil, 0})
+
+		return buf.String(), err
+	}
+
+	if _, err := run("set role=admin msg='hello world' empty="); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run(`echo $msg "${role}s" $REPLYME_TEST_HOME $missing`)
+	if err != nil || out != "hello world|admins|/home/test\n" {
+		t.Errorf("got %q, %v", out, err)
+	}
+
+	out, err = run("echo $(users list | filter --role $role) done")
+	if err != nil || out != "alice\ncarol|done\n" {
+		t.Errorf("got %q, %v", out, err)
+	}
+
+	if _, err = run("unset msg"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run("vars")
+	if err != nil || out != "empty=\nrole=admin\n" {
+		t.Errorf("vars printed %q, %v", out, err)
+	}
+
+	if _, err = run("set 1x=y"); !errors.Is(err, ErrorInvalidVariable) {
+		t.Errorf("got %v, want an invalid variable error", err)
+	}
+
+	if _, err = run("set foo"); !errors.Is(err, ErrorInvalidAssignment) {
+		t.Errorf("got %v, want an invalid assignment error", err)
+	}
+
+	other := newPipelineApp()
+	other.setSessionCommands()
+
+	buf := &bytes.Buffer{}
+	err = fullRunCommand(fullRunCommandParams{"vars", other, logs, nil, buf, buf, func(logMsg) {}, nil, nil, false, nil, nil, 0})
+	if err != nil || buf.Len() != 0 {
+		t.Errorf("another app has the variables %q, %v", buf.String(), err)
+	}
+
+	file := filepath.Join(t.TempDir(), "out.txt")
+	if _, err = run("set f=" + quoteWord(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = run(`echo $role > "$f"`); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(file); err != nil || string(data) != "admin\n" {
+		t.Errorf("the redirect target is not expanded: got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,127 @@
+package replyme
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// completeCommandName is the hidden entry point that the completion scripts call back into.
+// It receives the words of the command line, the last of which is the word under the cursor,
+// and prints one candidate per line.
+const completeCommandName = "__complete"
+
+// completionCommandName is the name of the built-in command that prints a completion script.
+const completionCommandName = "completion"
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{ .Name }}
+_{{ .Func }}_completion() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" ` + completeCommandName + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _{{ .Func }}_completion {{ .Name }}
+`,
+	"zsh": `#compdef {{ .Name }}
+# zsh completion for {{ .Name }}
+_{{ .Func }}() {
+    local -a candidates
+    candidates=(${(f)"$(${words[1]} ` + completeCommandName + ` "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -Q -- $candidates
+}
+compdef _{{ .Func }} {{ .Name }}
+`,
+	"fish": `# fish completion for {{ .Name }}
+function __{{ .Func }}_complete
+    set -l tokens (commandline -opc)
+    $tokens[1] ` + completeCommandName + ` $tokens[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c {{ .Name }} -f -a '(__{{ .Func }}_complete)'
+`,
+	"powershell": `# PowerShell completion for {{ .Name }}
+Register-ArgumentCompleter -Native -CommandName '{{ .Name }}' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') {
+        $words += ''
+    }
+    & $commandAst.CommandElements[0].ToString() ` + completeCommandName + ` @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+var completionFuncName = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// GenerateCompletion writes a completion script for the specified shell (bash, zsh, fish or powershell).
+// The script completes commands, subcommands, flags and arguments of the application
+// by calling the application back, so the Complete functions of flags and arguments also work from the shell.
+func (a *App) GenerateCompletion(shell string, w io.Writer) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return newErrorUnknownShell(shell)
+	}
+
+	name := a.Name
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+
+	t, err := template.New("completion").Parse(script)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, struct {
+		Name string
+		Func string
+	}{
+		Name: name,
+		Func: completionFuncName.ReplaceAllString(name, "_"),
+	})
+}
+
+func (a *App) setCompletionCommand() {
+	shell := &Argument{
+		Name:  "shell",
+		Usage: strings.Join(completionShells, ", "),
+		Complete: func(_ *Context, _ string) []string {
+			return completionShells
+		},
+	}
+
+	a.addBuiltinCommands(&Command{
+		Name:      completionCommandName,
+		Usage:     L(i18n_app_completion_usage),
+		Arguments: []*Argument{shell},
+		Action: func(ctx *Context) error {
+			return a.GenerateCompletion(MustGetArg[string](ctx, shell.Name), ctx.Stdout())
+		},
+	})
+}
+
+// runCompleteRequest answers the completion scripts: args are the words after the program name,
+// the last one being the word under the cursor.
+func runCompleteRequest(app *App, args []string, w io.Writer) error {
+	var partial string
+
+	if len(args) > 0 {
+		partial = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	for _, candidate := range app.completeWords(args, partial).Candidates {
+		if _, err := fmt.Fprintln(w, candidate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApp_GenerateCompletion(t *testing.T) {
+	app := newCompletionApp()
+	app.Name = "my-app"
+
+	for _, shell := range completionShells {
+		buf := &bytes.Buffer{}
+
+		err := app.GenerateCompletion(shell, buf)
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+
+		if !strings.Contains(buf.String(), "my-app") || !strings.Contains(buf.String(), completeCommandName) {
+			t.Errorf("%s: the script must call back into the application:\n%s", shell, buf.String())
+		}
+
+		if shell != "powershell" && !strings.Contains(buf.String(), "my_app") {
+			t.Errorf("%s: the script must use a sanitized function name:\n%s", shell, buf.String())
+		}
+	}
+
+	err := app.GenerateCompletion("tcsh", &bytes.Buffer{})
+	if !errors.Is(err, ErrorUnknownShell) {
+		t.Errorf("expected unknown shell error, got %v", err)
+	}
+}
+
+func TestRunCompleteRequest(t *testing.T) {
+	app := newCompletionApp()
+	buf := &bytes.Buffer{}
+
+	err := runCompleteRequest(app, []string{"deploy", "--env", ""}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "dev\nstage\nprod\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestApp_SetCompletionCommand(t *testing.T) {
+	err := i18nInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newCompletionApp()
+	app.setCompletionCommand()
+	app.setCompletionCommand()
+
+	buf := &bytes.Buffer{}
+
+	err = fullRunCommand(fullRunCommandParams{
+		"completion fish", app, make(chan log, 10), nil, buf, buf,
+		func(logMsg) {}, nil, nil, true, nil, nil, 0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "complete -c") {
+		t.Errorf("got %q", buf.String())
+	}
+
+	if len(app.Commands) != 4 {
+		t.Errorf("the completion command must be added once, got %d commands", len(app.Commands))
+	}
+}
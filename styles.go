@@ -8,6 +8,9 @@ var redIcon = lipgloss.NewStyle().
 var greenIcon = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("40"))
 
+var yellowIcon = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("214"))
+
 var inputContainer = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
 
 type stylesStruct struct {
@@ -0,0 +1,117 @@
+package replyme
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of suggestions shown for a mistyped name.
+const maxSuggestions = 3
+
+// suggester is implemented by the errors that carry "did you mean" suggestions.
+type suggester interface {
+	GetSuggestions() []string
+}
+
+// errorSuggestions returns the suggestions carried by the error or any error it wraps.
+func errorSuggestions(err error) []string {
+	var s suggester
+	if errors.As(err, &s) {
+		return s.GetSuggestions()
+	}
+
+	return nil
+}
+
+// renderSuggestions returns the "did you mean" hint for the suggestions, or "" if there are none.
+func renderSuggestions(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s?", L(i18n_did_you_mean), strings.Join(suggestions, ", "))
+}
+
+// suggest returns the candidates that are close to the mistyped name, the closest first.
+// A candidate is close if it is within a small edit distance or starts with the name.
+func suggest(name string, candidates []string) []string {
+	type scored struct {
+		candidate string
+		distance  int
+	}
+
+	var matches []scored
+
+	for _, candidate := range candidates {
+		if candidate == "" || slices.ContainsFunc(matches, func(s scored) bool { return s.candidate == candidate }) {
+			continue
+		}
+
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if d <= maxSuggestionDistance(name) || strings.HasPrefix(candidate, name) {
+			matches = append(matches, scored{candidate, d})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		return a.distance - b.distance
+	})
+
+	result := make([]string, 0, min(len(matches), maxSuggestions))
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		result = append(result, matches[i].candidate)
+	}
+
+	return result
+}
+
+// maxSuggestionDistance allows one typo per three characters, but at least one and at most three.
+func maxSuggestionDistance(name string) int {
+	return min(max(len([]rune(name))/3, 1), 3) //nolint:mnd
+}
+
+// editDistance returns the Damerau-Levenshtein distance (with adjacent transpositions) between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
+
+// commandNames returns the names and aliases of the commands.
+func commandNames(commands commandsSchema) []string {
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		names = append(names, command.Name)
+		names = append(names, command.Aliases...)
+	}
+
+	return names
+}
@@ -0,0 +1,72 @@
+package replyme
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"deploy", "deploy", 0},
+		{"deploy", "depoly", 1},
+		{"deploy", "deplo", 1},
+		{"deploy", "redeploy", 2},
+		{"status", "start", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"deploy", "delete", "users", "dep", "status"}
+
+	if got := suggest("depoly", candidates); !reflect.DeepEqual(got, []string{"deploy"}) {
+		t.Errorf("got %v, want [deploy]", got)
+	}
+
+	if got := suggest("de", candidates); !reflect.DeepEqual(got, []string{"dep", "deploy", "delete"}) {
+		t.Errorf("got %v, want the commands starting with de", got)
+	}
+
+	if got := suggest("xyz", candidates); len(got) != 0 {
+		t.Errorf("got %v, want no suggestions", got)
+	}
+}
+
+func TestParseCommand_Suggestions(t *testing.T) {
+	commands := commandsSchema{
+		{Name: "deploy", Subcommands: []commandSchema{{Name: "status"}, {Name: "start"}}},
+		{Name: "users", Aliases: []string{"u"}},
+	}
+
+	_, err := parseCommand(commands, flagSchema{}, argsSchema{}, "usres", parseOptions{})
+
+	var unknown *UnknownCommandError
+	if !errors.As(err, &unknown) || !errors.Is(err, ErrorUnknownCommand) {
+		t.Fatalf("got %v, want an unknown command error", err)
+	}
+
+	if !reflect.DeepEqual(errorSuggestions(err), []string{"users"}) {
+		t.Errorf("got suggestions %v, want [users]", errorSuggestions(err))
+	}
+
+	_, err = parseCommand(commands, flagSchema{}, argsSchema{}, "deploy stauts", parseOptions{})
+
+	var unknownSub *UnknownSubcommandError
+	if !errors.As(err, &unknownSub) || !errors.Is(err, ErrorSubcommandUnknown) || unknownSub.Command != "deploy" {
+		t.Fatalf("got %v, want an unknown subcommand error", err)
+	}
+
+	if !reflect.DeepEqual(unknownSub.Suggestions, []string{"status", "start"}) {
+		t.Errorf("got suggestions %v, want [status start]", unknownSub.Suggestions)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 const maxLines = 10
 const padding = 2
+const maxCompletionLines = 5
 
 type inputResizeMsg struct {
 	Delta int
@@ -25,10 +26,22 @@ type terminalInput struct {
 	lastLineCount int
 	running       bool
 
+	complete   func(line string) completion
+	completion completionState
+
 	viewport viewport.Model
 }
 
-func newTerminalInput() terminalInput {
+// completionState - the state of the candidate popup, which is shown while cycling with Tab.
+type completionState struct {
+	active     bool
+	candidates []string
+	selected   int
+	start      int
+	end        int
+}
+
+func newTerminalInput(complete func(line string) completion) terminalInput {
 	vp := viewport.New(standardWidth, 1)
 	vp.SetContent("")
 
@@ -37,6 +50,7 @@ func newTerminalInput() terminalInput {
 		width:     standardWidth,
 		viewport:  vp,
 		historyIx: 0,
+		complete:  complete,
 	}
 }
 
@@ -58,6 +72,12 @@ func (m terminalInput) Update(msg tea.Msg) (terminalInput, tea.Cmd) {
 func (m terminalInput) View() string {
 	if m.running {
 		m.viewport.SetContent(styles.GrayStyle(L(i18n_cmd_input_running)))
+
+		return m.viewport.View()
+	}
+
+	if m.completion.active {
+		return m.renderCompletion() + "\n" + m.viewport.View()
 	}
 
 	return m.viewport.View()
@@ -67,7 +87,12 @@ func (m terminalInput) Value() string {
 	return m.text
 }
 
+// GetLines returns the number of lines occupied by the input, including the completion popup.
 func (m terminalInput) GetLines() int {
+	return m.textLines() + m.completionLines()
+}
+
+func (m terminalInput) textLines() int {
 	if len(m.lines) > maxLines {
 		return maxLines
 	}
@@ -75,9 +100,25 @@ func (m terminalInput) GetLines() int {
 	return len(m.lines)
 }
 
+func (m terminalInput) completionLines() int {
+	if !m.completion.active || m.running {
+		return 0
+	}
+
+	return min(len(m.completion.candidates), maxCompletionLines)
+}
+
 //nolint:cyclop,funlen
 func (m terminalInput) onKey(msg tea.KeyMsg) (terminalInput, tea.Cmd) {
+	if msg.Type != tea.KeyTab && msg.Type != tea.KeyShiftTab {
+		m.completion = completionState{}
+	}
+
 	switch msg.Type {
+	case tea.KeyTab:
+		m.onTab(1)
+	case tea.KeyShiftTab:
+		m.onTab(-1)
 	case tea.KeyRunes, tea.KeySpace:
 		r := msg.String()
 		runes := []rune(m.text)
@@ -134,7 +175,7 @@ func (m terminalInput) onKey(msg tea.KeyMsg) (terminalInput, tea.Cmd) {
 
 	m.recalculateLines()
 	m.viewport.SetContent(m.render())
-	m.viewport.Height = m.GetLines()
+	m.viewport.Height = m.textLines()
 
 	if len(m.lines) > maxLines {
 		m.viewport.YOffset = len(m.lines) - maxLines
@@ -142,9 +183,9 @@ func (m terminalInput) onKey(msg tea.KeyMsg) (terminalInput, tea.Cmd) {
 		m.viewport.YOffset = 0
 	}
 
-	if len(m.lines) != m.lastLineCount {
-		delta := len(m.lines) - m.lastLineCount
-		m.lastLineCount = len(m.lines)
+	if lines := m.GetLines(); lines != m.lastLineCount {
+		delta := lines - m.lastLineCount
+		m.lastLineCount = lines
 
 		return m, func() tea.Msg {
 			return inputResizeMsg{Delta: delta}
@@ -154,6 +195,81 @@ func (m terminalInput) onKey(msg tea.KeyMsg) (terminalInput, tea.Cmd) {
 	return m, nil
 }
 
+// onTab completes the word under the cursor. If there are several candidates, the first press
+// inserts their common prefix and opens the popup, and the next presses cycle through them.
+func (m *terminalInput) onTab(step int) {
+	if m.complete == nil || m.running {
+		return
+	}
+
+	if m.completion.active {
+		n := len(m.completion.candidates)
+		m.completion.selected = ((m.completion.selected+step)%n + n) % n
+		m.replaceCompletion(m.completion.candidates[m.completion.selected])
+
+		return
+	}
+
+	runes := []rune(m.text)
+	c := m.complete(string(runes[:m.cursor]))
+
+	switch len(c.Candidates) {
+	case 0:
+		return
+	case 1:
+		m.completion = completionState{start: c.Start, end: m.cursor}
+		candidate := c.Candidates[0]
+
+		if !strings.HasSuffix(candidate, "=") {
+			candidate += " "
+		}
+
+		m.replaceCompletion(candidate)
+		m.completion = completionState{}
+	default:
+		m.completion = completionState{
+			active:     true,
+			candidates: c.Candidates,
+			selected:   -1,
+			start:      c.Start,
+			end:        m.cursor,
+		}
+
+		if prefix := commonPrefix(c.Candidates); len(prefix) > len(c.Partial) {
+			m.replaceCompletion(prefix)
+		}
+	}
+}
+
+func (m *terminalInput) replaceCompletion(s string) {
+	runes := []rune(m.text)
+	m.text = string(runes[:m.completion.start]) + s + string(runes[m.completion.end:])
+	m.completion.end = m.completion.start + utf8.RuneCountInString(s)
+	m.cursor = m.completion.end
+}
+
+func (m terminalInput) renderCompletion() string {
+	candidates := m.completion.candidates
+	from := 0
+
+	if len(candidates) > maxCompletionLines && m.completion.selected >= maxCompletionLines {
+		from = m.completion.selected - maxCompletionLines + 1
+	}
+
+	to := min(from+maxCompletionLines, len(candidates))
+	lines := make([]string, 0, to-from)
+
+	for i := from; i < to; i++ {
+		if i == m.completion.selected {
+			lines = append(lines, styles.InputSelected("  "+candidates[i]))
+		} else {
+			lines = append(lines, styles.GrayStyle("  "+candidates[i]))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func (m terminalInput) onWinResize(msg tea.WindowSizeMsg) (terminalInput, tea.Cmd) {
 	m.width = msg.Width
 	m.viewport.Width = msg.Width
@@ -166,9 +282,9 @@ func (m terminalInput) onWinResize(msg tea.WindowSizeMsg) (terminalInput, tea.Cm
 		m.viewport.YOffset = 0
 	}
 
-	if len(m.lines) != m.lastLineCount {
-		delta := len(m.lines) - m.lastLineCount
-		m.lastLineCount = len(m.lines)
+	if lines := m.GetLines(); lines != m.lastLineCount {
+		delta := lines - m.lastLineCount
+		m.lastLineCount = lines
 
 		return m, func() tea.Msg {
 			return inputResizeMsg{Delta: delta}
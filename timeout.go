@@ -0,0 +1,215 @@
+package replyme
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const timeoutFlagName = "timeout"
+
+// timeoutFlag is the --timeout flag added to every command. It has its own type,
+// so that it is not confused with a timeout flag the application declares itself.
+type timeoutFlag struct {
+	*FlagValue[time.Duration]
+}
+
+func (f timeoutFlag) cloneFlag() FlagDefinition {
+	return timeoutFlag{f.FlagValue.cloneFlag().(*FlagValue[time.Duration])}
+}
+
+func (f timeoutFlag) mirrorFlag(run FlagDefinition) {
+	if r, ok := run.(timeoutFlag); ok {
+		f.FlagValue.mirrorFlag(r.FlagValue)
+	}
+}
+
+func (a *App) setTimeoutFlags() {
+	setTimeoutFlag(a.Commands, a.GlobalFlags)
+}
+
+// setTimeoutFlag adds --timeout to the commands that neither declare nor inherit a flag with this name.
+func setTimeoutFlag(commands []*Command, inherited Flags) {
+	for _, command := range commands {
+		visible := append(slices.Clone(inherited), command.PersistentFlags...)
+
+		if findFlag(append(slices.Clone(visible), command.Flags...), timeoutFlagName) == nil {
+			command.Flags = append(command.Flags, timeoutFlag{&FlagValue[time.Duration]{
+				Name:  timeoutFlagName,
+				Usage: L(i18n_app_timeout_usage),
+			}})
+		}
+
+		if len(command.Subcommands) > 0 {
+			setTimeoutFlag(command.Subcommands, visible)
+		}
+	}
+}
+
+// commandTimeout returns how long the command the flow runs can take: the --timeout given to the command
+// or to its nearest parent, the Timeout of the command or of its nearest parent that has one,
+// or App.DefaultTimeout unless the command waits for other commands. 0 is no timeout.
+func (a *App) commandTimeout(flow []*Command) time.Duration {
+	for i := len(flow) - 1; i >= 0; i-- {
+		for _, flag := range flow[i].Flags {
+			if f, ok := flag.(timeoutFlag); ok && f.IsSet() {
+				return f.value
+			}
+		}
+	}
+
+	for i := len(flow) - 1; i >= 0; i-- {
+		if flow[i].Timeout > 0 {
+			return flow[i].Timeout
+		}
+	}
+
+	if flow[len(flow)-1].waitsForCommands {
+		return 0
+	}
+
+	return a.DefaultTimeout
+}
+
+// runWithTimeout runs the flow with a context that is cancelled after its timeout. A command that does not stop
+// when its context is cancelled is abandoned, so that it does not block the REPL, and its output is closed.
+// If the flow is cancelled by parent, it is waited for.
+func (a *App) runWithTimeout(
+	parent context.Context, name string, flow []*Command, run func(ctx context.Context, out *runOutput) error,
+) error {
+	out := &runOutput{}
+
+	timeout := a.commandTimeout(flow)
+	if timeout <= 0 {
+		return run(parent, out)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		done <- run(ctx, out)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+		if err == nil {
+			return nil
+		}
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return <-done
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		out.close()
+
+		return &TimeoutError{Command: name, Timeout: timeout, Elapsed: time.Since(start)}
+	}
+
+	return err
+}
+
+// runOutput is the output of a run: its stdout and stderr, logs and prompts. runWithTimeout closes it
+// when it abandons the run, so that a command that does not stop does not write into the output of the commands
+// that run after it, e.g. into the input of the next stage of a pipeline.
+type runOutput struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+// close closes the output. It waits for the write that is in progress.
+func (o *runOutput) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.closed = true
+}
+
+func (o *runOutput) isClosed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.closed
+}
+
+// do calls f unless the output is closed, and reports whether it did.
+func (o *runOutput) do(f func()) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return false
+	}
+
+	f()
+
+	return true
+}
+
+// params returns p with the output of the run.
+func (o *runOutput) params(p fullRunCommandParams) fullRunCommandParams {
+	p.stdout = o.writer(p.stdout)
+	p.stderr = o.writer(p.stderr)
+
+	if emitLog := p.emitLog; emitLog != nil {
+		p.emitLog = func(l logMsg) {
+			o.do(func() { emitLog(l) })
+		}
+	}
+
+	// The prompts wait for the user, so they are not waited for by close. They are closed with the context of the run
+	if emitTUI := p.emitTUI; emitTUI != nil {
+		p.emitTUI = func(t TUIRequest) {
+			if !o.isClosed() {
+				emitTUI(t)
+			}
+		}
+	}
+
+	if emitTUICLI := p.emitTUICLI; emitTUICLI != nil {
+		p.emitTUICLI = func(t TUIRequest, closed chan<- bool) {
+			if o.isClosed() {
+				close(closed)
+
+				return
+			}
+
+			emitTUICLI(t, closed)
+		}
+	}
+
+	return p
+}
+
+// writer returns w that writes nothing once the output is closed.
+func (o *runOutput) writer(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+
+	return runOutputWriter{o, w}
+}
+
+type runOutputWriter struct {
+	out *runOutput
+	w   io.Writer
+}
+
+func (w runOutputWriter) Write(p []byte) (n int, err error) {
+	if !w.out.do(func() { n, err = w.w.Write(p) }) {
+		return 0, ErrorRunAbandoned
+	}
+
+	return n, err
+}
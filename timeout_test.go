@@ -0,0 +1,270 @@
+package replyme
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTimeoutApp() *App {
+	app := &App{
+		Name:           "test",
+		DefaultTimeout: time.Minute,
+		Commands: Commands{
+			{
+				Name:            "deploy",
+				Timeout:         50 * time.Millisecond,
+				PersistentFlags: Flags{&FlagValue[string]{Name: "env"}},
+				Subcommands: Commands{
+					{
+						Name: "hang",
+						Action: func(*Context) error {
+							// Ignores the cancellation
+							time.Sleep(time.Second)
+
+							return nil
+						},
+					},
+					{
+						Name: "sleep",
+						Action: func(ctx *Context) error {
+							_, _, err := ctx.Exec("sleep", "5")
+
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name: "wait",
+				Action: func(ctx *Context) error {
+					<-ctx.Done()
+
+					return ctx.Ctx().Err()
+				},
+			},
+			{
+				Name:  "http",
+				Flags: Flags{&FlagValue[int]{Name: "timeout"}},
+			},
+			{
+				Name:            "db",
+				PersistentFlags: Flags{&FlagValue[time.Duration]{Name: "timeout"}},
+				Subcommands:     Commands{{Name: "query"}},
+			},
+		},
+	}
+	app.setTimeoutFlags()
+
+	return app
+}
+
+func TestSetTimeoutFlags(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTimeoutApp()
+
+	tests := []struct {
+		path  []string
+		added bool
+	}{
+		{[]string{"deploy"}, true},
+		{[]string{"deploy", "hang"}, true},
+		{[]string{"http"}, false},
+		{[]string{"db"}, false},
+		{[]string{"db", "query"}, false},
+	}
+
+	for _, tt := range tests {
+		path, err := app.Commands.getCommandPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, added := findFlag(path[len(path)-1].Flags, timeoutFlagName).(timeoutFlag)
+		if added != tt.added {
+			t.Errorf("%s: --timeout added: %v, want %v", commandPath(tt.path), added, tt.added)
+		}
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTimeoutApp()
+
+	run := func(command string) (time.Duration, error) {
+		start := time.Now()
+		buf := &bytes.Buffer{}
+		err := fullRunCommand(fullRunCommandParams{command, app, make(chan log, 10), nil, buf, buf, func(logMsg) {}, nil, nil, true, nil, nil, 0})
+
+		return time.Since(start), err
+	}
+
+	tests := []struct {
+		command string
+		timeout time.Duration
+	}{
+		{"deploy hang", 50 * time.Millisecond},
+		{"deploy sleep", 50 * time.Millisecond},
+		{"wait --timeout 20ms", 20 * time.Millisecond},
+		{"deploy --timeout 1h hang --timeout 20ms", 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		elapsed, err := run(tt.command)
+
+		var timeout *TimeoutError
+		if !errors.As(err, &timeout) || !errors.Is(err, ErrorCommandTimeout) || timeout.Timeout != tt.timeout {
+			t.Errorf("%s: got %v, want a timeout after %s", tt.command, err, tt.timeout)
+		}
+
+		if elapsed > 500*time.Millisecond {
+			t.Errorf("%s: the command ran for %s after its timeout", tt.command, elapsed)
+		}
+	}
+
+	for _, command := range []string{"deploy hang --timeout 0", "deploy --timeout 0 hang"} {
+		if _, err := run(command); err != nil {
+			t.Errorf("%s: got %v, want --timeout 0 to disable the timeout", command, err)
+		}
+	}
+}
+
+func TestCommandTimeout_Builtins(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{DefaultTimeout: time.Minute, EnvPrefix: "TEST"}
+	app.setJobCommands()
+	app.setSourceCommand()
+	app.setTimeoutFlags()
+
+	for _, name := range []string{fgCommandName, waitCommandName, sourceCommandName} {
+		if got := app.commandTimeout([]*Command{app.Commands.mustGetCommand(name)}); got != 0 {
+			t.Errorf("%s: got timeout %s, want no default timeout", name, got)
+		}
+	}
+
+	jobs := app.Commands.mustGetCommand(jobsCommandName)
+	if got := app.commandTimeout([]*Command{jobs}); got != time.Minute {
+		t.Errorf("jobs: got timeout %s, want the default timeout", got)
+	}
+
+	if envs := flagEnvVars(findFlag(jobs.Flags, timeoutFlagName), app.EnvPrefix); len(envs) != 0 {
+		t.Errorf("--timeout is bound to %v, want no environment variables", envs)
+	}
+}
+
+func TestReportCommandError_Timeout(t *testing.T) {
+	m := &model{modelLogs: modelLogs{logsChan: make(chan log, 3)}}
+
+	m.reportCommandError("deploy", &TimeoutError{Command: "deploy", Timeout: time.Second, Elapsed: 1500 * time.Millisecond})
+	close(m.logsChan)
+
+	l := <-m.logsChan
+	if l.Type != logTypeCommandTimeout || l.Message != "1.5s" || len(m.logsChan) != 0 {
+		t.Errorf("got %+v, want a single timeout entry with the elapsed time", l)
+	}
+}
+
+func TestCommandTimeout_Abandoned(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+
+	app := &App{Commands: Commands{
+		{
+			Name:    "late",
+			Timeout: 20 * time.Millisecond,
+			Action: func(ctx *Context) error {
+				// Ignores the cancellation
+				time.Sleep(100 * time.Millisecond)
+				ctx.Print("late")
+				_, err := ctx.Stdout().Write([]byte("late\n"))
+				done <- err
+
+				return err
+			},
+		},
+		{
+			Name:    "ask",
+			Timeout: 20 * time.Millisecond,
+			Action: func(ctx *Context) error {
+				_, err := ctx.Confirm(&TUIConfirmParams{Name: "Deploy?"})
+				done <- err
+
+				return err
+			},
+		},
+	}}
+	app.setTimeoutFlags()
+
+	var logged []logMsg
+
+	buf := &bytes.Buffer{}
+	noAnswer := func(TUIRequest) {}
+
+	for _, command := range []string{"late", "ask"} {
+		err := fullRunCommand(fullRunCommandParams{
+			command, app, make(chan log, 10), nil, buf, buf,
+			func(l logMsg) { logged = append(logged, l) }, noAnswer, nil, false, nil, nil, 0,
+		})
+
+		var timeout *TimeoutError
+		if !errors.As(err, &timeout) {
+			t.Errorf("%s: got %v, want a timeout", command, err)
+		}
+
+		select {
+		case err = <-done:
+			if err == nil {
+				t.Errorf("%s: the abandoned command wrote its output", command)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: the command is still running", command)
+		}
+	}
+
+	if buf.Len() != 0 || len(logged) != 0 {
+		t.Errorf("the abandoned commands printed %q and %d logs", buf.String(), len(logged))
+	}
+}
+
+func TestCommandTimeout_Config(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{
+		Name:        "test",
+		ConfigFiles: []string{writeConfigFile(t, "app.toml", "[deploy]\ntimeout = 30\n")},
+		Commands:    Commands{{Name: "deploy"}},
+	}
+	app.setConfigCommand()
+	app.setTimeoutFlags()
+
+	var err error
+
+	if app.config, err = loadConfigFiles(app.ConfigFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = fullRunCommand(fullRunCommandParams{"deploy", app, make(chan log, 10), nil, buf, buf, func(logMsg) {}, nil, nil, false, nil, nil, 0}); err != nil {
+		t.Errorf("got %v, want the timeout key of the command not to be read into --timeout", err)
+	}
+
+	if err = app.showConfig(buf, nil); err != nil || strings.Contains(buf.String(), "--timeout") {
+		t.Errorf("config show printed %q, %v, want no --timeout", buf.String(), err)
+	}
+}
@@ -1,5 +1,7 @@
 package replyme
 
+import "context"
+
 func (m *model) emitTUI(t TUIRequest) {
 	m.tuiChan <- t
 }
@@ -83,6 +85,8 @@ type TUIRequest struct {
 	Type     tuiType
 	Payload  interface{}
 	Response chan TUIResponse
+	// The context of the command that opened the prompt, which is closed when the command is cancelled
+	ctx context.Context
 }
 
 type TUIResponse struct {
@@ -1,9 +1,11 @@
 package replyme
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/danyasatsuk/replyme/internal/filepicker"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +14,19 @@ import (
 
 const scrollLines = 3
 
+// forceCancelWindow is how soon after the first Ctrl+C the second one abandons the running command line.
+const forceCancelWindow = 2 * time.Second
+
+// commandLineDoneMsg is sent when the command line with the ID ends.
+type commandLineDoneMsg int
+
+// runningCommandMsg is sent by the command line with the ID line when one of its commands starts.
+// The commands run in another goroutine, so the model is only changed in Update.
+type runningCommandMsg struct {
+	line    int
+	command string
+}
+
 func (m *model) tuiUpdater(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -47,7 +62,7 @@ func (m *model) updateLogsHeight() {
 		m.tuiViewport.Height = m.windowHeight
 	} else {
 		m.tuiViewport.Height = 0
-		m.logsViewport.Height = m.windowHeight - m.input.GetLines()
+		m.logsViewport.Height = m.windowHeight - m.input.GetLines() - strings.Count(m.jobsStatus(), "\n")
 	}
 }
 
@@ -97,7 +112,17 @@ func (m *model) helpFunc(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
-		return m, tea.Quit
+		if m.cancelLine == nil {
+			return m, tea.Quit
+		}
+
+		m.cancelCommandLine()
+
+		return m, nil
+	case "ctrl+d":
+		if m.cancelLine == nil && !m.isRunningTUI {
+			return m, tea.Quit
+		}
 	case "enter":
 		if m.isRunningTUI {
 			return m.tuiUpdater(msg)
@@ -116,39 +141,34 @@ func (m *model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m.helpFunc(msg)
 		}
 
-		m.logs.Add(logTypeCommandRunning, command)
-		m.logsViewport.SetContent(wordwrap.String(m.logs.Render(), m.logsViewport.Width))
-		m.logsViewport, _ = m.logsViewport.Update(msg)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		m.lineID++
+		id := m.lineID
+
 		m.runningCommand = command
+		m.cancelLine = cancel
+		m.cancelledAt = time.Time{}
 		m.input.running = true
 		m.input, _ = m.input.Update(msg)
 
-		go func() {
-			err := m.runCommand(command)
-			if err != nil {
-				typeOfError := logTypeError
-
-				switch {
-				case errors.Is(err, ErrorUnknownCommand):
-					typeOfError = logTypeCommandNotFound
-				case errors.Is(err, ErrorCommandPanic):
-					typeOfError = logTypePanic
-				}
-				m.logsChan <- log{typeOfError, command, err.Error(), err, time.Now()}
-				m.logsChan <- log{logTypeCommandFailure, command, err.Error(), err, time.Now()}
-			}
-		}()
+		return m, tea.Batch(ticker(), func() tea.Msg {
+			m.runCommandLine(ctx, id, command)
+			cancel()
 
-		return m, tea.Batch(ticker())
+			return commandLineDoneMsg(id)
+		})
 	}
 
 	if m.isRunningTUI {
 		return m.tuiUpdater(msg)
 	}
 
-	m.input, _ = m.input.Update(msg)
+	var cmd tea.Cmd
 
-	return m, nil
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
 }
 
 func (m *model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
@@ -168,24 +188,104 @@ func (m *model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *model) onLogsChan(l log, msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.addLog(l)
+	m.logsViewport, _ = m.logsViewport.Update(msg)
+
+	return m, ticker()
+}
+
+func (m *model) addLog(l log) {
 	l.Message = wordwrap.String(l.Message, m.logsViewport.Width)
 	m.logs.AddLog(l)
 	m.updateLogsHeight()
 	m.logsViewport.SetContent(m.logs.Render())
 	m.logsViewport.GotoBottom()
-	m.logsViewport, _ = m.logsViewport.Update(msg)
+}
 
-	if l.Type == logTypeCommandSuccess || l.Type == logTypeCommandFailure || l.Type == logTypeCommandNotFound {
-		m.input.running = false
-		m.input, _ = m.input.Update(msg)
+// reportCommandError adds the error of the command to the logs and marks the command as failed,
+// or as cancelled or timed out.
+func (m *model) reportCommandError(command string, err error) {
+	if errors.Is(err, context.Canceled) {
+		m.logsChan <- log{logTypeCommandCancelled, command, err.Error(), err, time.Now()}
 
-		return m, tea.Batch(ticker())
+		return
 	}
 
-	return m, ticker()
+	var timeout *TimeoutError
+	if errors.As(err, &timeout) {
+		m.logsChan <- log{logTypeCommandTimeout, command, timeout.Elapsed.Round(time.Millisecond).String(), err, time.Now()}
+
+		return
+	}
+
+	typeOfError := logTypeError
+
+	switch {
+	case errors.Is(err, ErrorUnknownCommand), errors.Is(err, ErrorSubcommandUnknown):
+		typeOfError = logTypeCommandNotFound
+	case errors.Is(err, ErrorCommandPanic):
+		typeOfError = logTypePanic
+	}
+	m.logsChan <- log{typeOfError, command, err.Error(), err, time.Now()}
+
+	if hint := renderSuggestions(errorSuggestions(err)); hint != "" {
+		m.logsChan <- log{logTypeSuggestion, command, hint, nil, time.Now()}
+	}
+
+	m.logsChan <- log{logTypeCommandFailure, command, err.Error(), err, time.Now()}
+}
+
+// cancelCommandLine handles Ctrl+C while a command line runs. The first press cancels its context and closes the open prompt,
+// a second press within forceCancelWindow abandons the line, for commands that do not stop when they are cancelled.
+func (m *model) cancelCommandLine() {
+	if !m.cancelledAt.IsZero() && time.Since(m.cancelledAt) < forceCancelWindow {
+		m.addLog(log{logTypeCommandCancelled, m.runningCommand, "", context.Canceled, time.Now()})
+		m.addLog(log{logTypeWarn, m.runningCommand, L(i18n_cmd_abandoned), nil, time.Now()})
+		m.finishCommandLine()
+
+		return
+	}
+
+	m.cancelledAt = time.Now()
+	m.cancelLine()
+	m.closeTUI(context.Canceled)
+	m.addLog(log{logTypeWarn, m.runningCommand, L(i18n_cmd_cancel_hint), nil, time.Now()})
+}
+
+// finishCommandLine unlocks the input after the command line ends or is abandoned.
+func (m *model) finishCommandLine() {
+	m.lineID++
+	m.runningCommand = ""
+	m.cancelLine = nil
+	m.cancelledAt = time.Time{}
+	m.input.running = false
+}
+
+// closeTUI closes the open prompt, the command that opened it gets err.
+func (m *model) closeTUI(err error) {
+	if !m.isRunningTUI {
+		return
+	}
+
+	response := m.runningTUI.Response
+
+	go func() {
+		response <- TUIResponse{Err: err}
+	}()
+
+	m.isRunningTUI = false
+	m.runningTUI = nil
+	m.tuiViewport.SetContent("")
+	m.updateLogsHeight()
+	m.logsViewport.GotoBottom()
 }
 
 func (m *model) onTUIChan(t TUIRequest, msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The command stopped waiting for the prompt before it was opened
+	if t.ctx != nil && t.ctx.Err() != nil {
+		return m, ticker()
+	}
+
 	m.isRunningTUI = true
 	m.runningTUI = &t
 	m.updateLogsHeight()
@@ -226,6 +326,13 @@ func (m *model) handleTickMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.onLogsChan(l, msg)
 	case t := <-m.tuiChan:
 		return m.onTUIChan(t, msg)
+	case r := <-m.runningChan:
+		// An abandoned line keeps running, but it no longer changes the state of the REPL
+		if r.line == m.lineID {
+			m.runningCommand = r.command
+		}
+
+		return m, ticker()
 	case <-m.tuiClose:
 		m.isRunningTUI = false
 		m.runningTUI = nil
@@ -237,11 +344,19 @@ func (m *model) handleTickMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return m, ticker()
 	default:
+		// The command that opened the prompt was cancelled or timed out
+		if m.isRunningTUI && m.runningTUI.ctx != nil && m.runningTUI.ctx.Err() != nil {
+			m.closeTUI(m.runningTUI.ctx.Err())
+		}
+
 		if m.runningCommand == "" {
 			m.input.running = false
 			m.input, _ = m.input.Update(msg)
 
-			return m, nil
+			// The logs of the background jobs are read on ticks too
+			if running, _ := m.app.jobs.counts(); running == 0 {
+				return m, nil
+			}
 		}
 
 		return m, ticker()
@@ -269,6 +384,12 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleKeyMsg(msg)
 	case tick:
 		return m.handleTickMsg(msg)
+	case commandLineDoneMsg:
+		if int(msg) == m.lineID {
+			m.finishCommandLine()
+		}
+
+		return m, nil
 	case tea.MouseMsg:
 		return m.handleMouseMsg(msg)
 	case filepicker.ReadDirMsg, filepicker.ErrorMsg:
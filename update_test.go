@@ -0,0 +1,123 @@
+package replyme
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCancelCommandLine(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := createModel(newScriptApp(nil))
+
+	if _, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlD}); cmd == nil {
+		t.Fatal("Ctrl+D at an idle prompt must quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("Ctrl+D at an idle prompt must quit")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	response := make(chan TUIResponse)
+
+	m.lineID = 1
+	m.runningCommand = "deploy"
+	m.cancelLine = cancel
+	m.isRunningTUI = true
+	m.runningTUI = &TUIRequest{Type: tuiTypeConfirm, Response: response}
+	m.logs.AddLog(log{Type: logTypeCommandRunning, Command: "deploy", Message: "deploy"})
+
+	if _, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlC}); cmd != nil || ctx.Err() == nil {
+		t.Fatal("the first Ctrl+C must cancel the running command instead of quitting")
+	}
+
+	if res := <-response; !errors.Is(res.Err, context.Canceled) || m.isRunningTUI {
+		t.Errorf("got %v, want the prompt to be closed with %v", res.Err, context.Canceled)
+	}
+
+	if m.runningCommand != "deploy" {
+		t.Fatal("the command line must keep running until it stops")
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+	if m.runningCommand != "" || m.cancelLine != nil || m.input.running {
+		t.Fatal("the second Ctrl+C must abandon the command line")
+	}
+
+	if (*m.logs)[0].Type != logTypeCommandCancelled {
+		t.Errorf("got log type %d, want the command to be marked as cancelled", (*m.logs)[0].Type)
+	}
+
+	// The abandoned line ends while the next one runs
+	m.runningCommand = "next"
+	m.Update(commandLineDoneMsg(1))
+
+	if m.runningCommand != "next" {
+		t.Error("the abandoned command line must not change the state of the next one")
+	}
+}
+
+func TestRunCommandLine_RunningCommand(t *testing.T) {
+	if err := i18nInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := createModel(newScriptApp(nil))
+
+	// run runs the command line with the ID while Update handles the ticks, and returns the running commands it saw
+	run := func(id int, line string) []string {
+		done := make(chan struct{})
+
+		go func() {
+			m.runCommandLine(context.Background(), id, line)
+			close(done)
+		}()
+
+		var seen []string
+
+		for {
+			select {
+			case <-done:
+				return seen
+			default:
+			}
+
+			m.Update(tick{})
+
+			if n := len(seen); m.runningCommand != "" && (n == 0 || seen[n-1] != m.runningCommand) {
+				seen = append(seen, m.runningCommand)
+			}
+		}
+	}
+
+	m.lineID = 1
+	if seen := run(1, "echo a; echo b"); !slices.Equal(seen, []string{"echo a", "echo b"}) {
+		t.Errorf("got running commands %q, want echo a and echo b", seen)
+	}
+
+	var outputs []string
+
+	for _, l := range *m.logs {
+		if l.Type == logTypeMessage {
+			outputs = append(outputs, l.Command+": "+l.Message)
+		}
+	}
+
+	if want := []string{"echo a: a", "echo b: b"}; !slices.Equal(outputs, want) {
+		t.Errorf("got the outputs %q, want %q", outputs, want)
+	}
+
+	// The line 1 was abandoned, and the line 2 runs
+	m.lineID = 2
+	m.runningCommand = "next"
+
+	if run(1, "echo c"); m.runningCommand != "next" {
+		t.Errorf("got running command %q, want the abandoned line not to change it", m.runningCommand)
+	}
+}
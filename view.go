@@ -1,5 +1,7 @@
 package replyme
 
+import "fmt"
+
 // View - method of the BubbleTea model.
 func (m *model) View() string {
 	if m.isRunningTUI {
@@ -19,5 +21,15 @@ func (m *model) View() string {
 		return m.logsViewport.View() + "\n" + m.tuiViewport.View()
 	}
 
-	return m.logsViewport.View() + " \n" + m.input.View()
+	return m.logsViewport.View() + " \n" + m.jobsStatus() + m.input.View()
+}
+
+// jobsStatus returns the status line with the number of background jobs, or nothing if there are none.
+func (m *model) jobsStatus() string {
+	running, finished := m.app.jobs.counts()
+	if running == 0 && finished == 0 {
+		return ""
+	}
+
+	return styles.GrayStyle(fmt.Sprintf(L(i18n_jobs_status), running, finished)) + "\n"
 }